@@ -0,0 +1,109 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Resources given to an ad-hoc task.
+type AdhocResources struct {
+	CPU    float64
+	RamMb  int64
+	DiskMb int64
+}
+
+// Run a command once on the cluster as a single instance batch job and wait for it to end. The
+// task is not retried. Returns the final state of the task; a task that ran but failed is not
+// an error, its status tells how it ended. On timeout the job is killed.
+func (r *RealisClient) RunAdhocTask(role, env, name, command string, resources AdhocResources,
+	timeout time.Duration) (*Task, error) {
+
+	job := NewJob().
+		Environment(env).
+		Role(role).
+		Name(name).
+		CPU(resources.CPU).
+		RAM(resources.RamMb).
+		Disk(resources.DiskMb).
+		IsService(false).
+		InstanceCount(1).
+		MaxFailure(1)
+
+	thermos := &ThermosExecutor{payload: thermosCommandPayload(job.JobKey(), command, resources)}
+	if err := thermos.Apply(job); err != nil {
+		return nil, err
+	}
+
+	response, err := r.CreateJob(job)
+	if err != nil {
+		return nil, err
+	}
+	if response.ResponseCode != aurora.ResponseCode_OK {
+		return nil, errors.Errorf("Unable to create ad-hoc job: %s", responseMessage(response))
+	}
+
+	results, err := r.AwaitCompletion(job.JobKey(), timeout)
+	if err != nil {
+		if _, killErr := r.KillJob(job.JobKey()); killErr != nil {
+			return nil, errors.Wrapf(err, "ad-hoc job could not be killed either (%v)", killErr)
+		}
+		return nil, err
+	}
+
+	return results[0].Task, nil
+}
+
+// Thermos payload running a single process, as the Aurora client would generate it.
+func thermosCommandPayload(key *aurora.JobKey, command string, resources AdhocResources) map[string]interface{} {
+	const mb = 1024 * 1024
+
+	process := map[string]interface{}{
+		"name":         key.Name,
+		"cmdline":      command,
+		"daemon":       false,
+		"ephemeral":    false,
+		"final":        false,
+		"max_failures": 1,
+		"min_duration": 5,
+	}
+
+	return map[string]interface{}{
+		"name":              key.Name,
+		"role":              key.Role,
+		"environment":       key.Environment,
+		"service":           false,
+		"production":        false,
+		"priority":          0,
+		"max_task_failures": 1,
+		"enable_hooks":      false,
+		"task": map[string]interface{}{
+			"name":              key.Name,
+			"processes":         []interface{}{process},
+			"constraints":       []interface{}{map[string]interface{}{"order": []string{key.Name}}},
+			"finalization_wait": 30,
+			"max_failures":      1,
+			"max_concurrency":   0,
+			"resources": map[string]interface{}{
+				"cpu":  resources.CPU,
+				"ram":  resources.RamMb * mb,
+				"disk": resources.DiskMb * mb,
+				"gpu":  0,
+			},
+		},
+	}
+}
@@ -0,0 +1,155 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type AlertType string
+
+const (
+	TaskStoreGrowthAlert AlertType = "TASK_STORE_GROWTH"
+	OfferStarvationAlert AlertType = "OFFER_STARVATION"
+)
+
+// Limits used by the scheduler poller to decide when a stat is anomalous. A zero value
+// disables the corresponding check.
+type AlertThresholds struct {
+	// Number of tasks the task store may grow by between two consecutive polls.
+	TaskStoreGrowth float64
+
+	// Number of PENDING tasks tolerated while the scheduler holds no outstanding offers.
+	PendingWithoutOffers float64
+}
+
+// Anomaly detected in the scheduler stats.
+type SchedulerAlert struct {
+	Type      AlertType
+	Stat      string
+	Value     float64
+	Threshold float64
+}
+
+// Fetch the numeric stats exported by the scheduler on its /vars.json endpoint.
+func (r *RealisClient) SchedulerStats() (map[string]float64, error) {
+	client := r.config.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Get(r.config.url + "/vars.json")
+	if err != nil {
+		return nil, errors.Wrap(err, "Error retrieving scheduler stats")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Scheduler stats endpoint returned %s", resp.Status)
+	}
+
+	vars := make(map[string]interface{})
+	if err := decodeJSON(resp.Body, &vars); err != nil {
+		return nil, errors.Wrap(err, "Error decoding scheduler stats")
+	}
+
+	stats := make(map[string]float64)
+	for name, value := range vars {
+		if num, ok := value.(float64); ok {
+			stats[name] = num
+		}
+	}
+
+	return stats, nil
+}
+
+// Poll the scheduler stats every interval, 5 seconds when zero, and publish a
+// SchedulerAlertEvent on the client's event channel for every anomaly found. Blocks until stop
+// is closed.
+func (r *RealisClient) PollSchedulerAlerts(interval time.Duration, thresholds AlertThresholds, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	var lastTaskStoreSize float64 = -1
+	for {
+		stats, err := r.SchedulerStats()
+		if err != nil {
+			r.emit(Event{Type: SchedulerStatsErrorEvent, Message: err.Error()})
+		} else {
+			taskStoreSize := taskStoreSize(stats)
+			for _, alert := range checkSchedulerStats(stats, taskStoreSize, lastTaskStoreSize, thresholds) {
+				r.emit(Event{
+					Type: SchedulerAlertEvent,
+					Message: fmt.Sprintf("%s: %s is %v (threshold %v)",
+						alert.Type, alert.Stat, alert.Value, alert.Threshold),
+					Payload: alert})
+			}
+			lastTaskStoreSize = taskStoreSize
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-r.clock.After(interval):
+		}
+	}
+}
+
+// Total number of tasks held by the scheduler, exported as one task_store_<STATUS> gauge per status.
+func taskStoreSize(stats map[string]float64) float64 {
+	var size float64
+	for name, value := range stats {
+		if strings.HasPrefix(name, "task_store_") {
+			size += value
+		}
+	}
+	return size
+}
+
+func checkSchedulerStats(
+	stats map[string]float64,
+	taskStoreSize float64,
+	lastTaskStoreSize float64,
+	thresholds AlertThresholds) []SchedulerAlert {
+
+	var alerts []SchedulerAlert
+
+	if thresholds.TaskStoreGrowth > 0 && lastTaskStoreSize >= 0 {
+		if growth := taskStoreSize - lastTaskStoreSize; growth > thresholds.TaskStoreGrowth {
+			alerts = append(alerts, SchedulerAlert{
+				Type:      TaskStoreGrowthAlert,
+				Stat:      "task_store_*",
+				Value:     growth,
+				Threshold: thresholds.TaskStoreGrowth})
+		}
+	}
+
+	if thresholds.PendingWithoutOffers > 0 && stats["outstanding_offers"] == 0 {
+		if pending := stats["task_store_PENDING"]; pending > thresholds.PendingWithoutOffers {
+			alerts = append(alerts, SchedulerAlert{
+				Type:      OfferStarvationAlert,
+				Stat:      "task_store_PENDING",
+				Value:     pending,
+				Threshold: thresholds.PendingWithoutOffers})
+		}
+	}
+
+	return alerts
+}
@@ -0,0 +1,70 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+)
+
+// Operations that require approval when an approver is set.
+var destructiveOperations = map[string]bool{
+	"KillJob":            true,
+	"KillInstance":       true,
+	"KillWithEscalation": true,
+	"RemoveInstances":    true,
+	"AbortJobUpdate":     true,
+}
+
+// Callback invoked before destructive operations. It may block until an external approval
+// system confirms the operation and returns an error if the operation was denied.
+type Approver func(op *Operation) error
+
+// Returned when an approver denies an operation.
+type ApprovalError struct {
+	Operation string
+	JobKey    *aurora.JobKey
+	Err       error
+}
+
+func (e *ApprovalError) Error() string {
+	return fmt.Sprintf("%s on %s/%s/%s was not approved: %v",
+		e.Operation, e.JobKey.Role, e.JobKey.Environment, e.JobKey.Name, e.Err)
+}
+
+func (e *ApprovalError) Cause() error {
+	return e.Err
+}
+
+// Require approval before killing jobs or instances and aborting updates.
+func (r *RealisClient) SetApprover(approver Approver) {
+	r.approver = approver
+}
+
+// Restrict an approver to operations on the given environments, approving all others right
+// away, e.g. to only require a second person for prod.
+func ApprovalForEnvironments(approver Approver, environments ...string) Approver {
+	required := make(map[string]bool)
+	for _, env := range environments {
+		required[env] = true
+	}
+
+	return func(op *Operation) error {
+		if !required[op.JobKey.Environment] {
+			return nil
+		}
+		return approver(op)
+	}
+}
@@ -0,0 +1,75 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Outcome of a job created with CreateJobAsync.
+type CreateResult struct {
+	JobKey   *aurora.JobKey
+	Response *aurora.Response
+
+	// How each instance ended, only set for batch jobs.
+	Instances []InstanceResult
+
+	Err error
+}
+
+// Create a job and follow it in the background. The returned channel receives a single result
+// once every instance of a service job is running, or once every instance of a batch job has
+// ended, or when timeout expires.
+func (r *RealisClient) CreateJobAsync(job *Job, timeout time.Duration) <-chan CreateResult {
+	results := make(chan CreateResult, 1)
+
+	go func() {
+		defer close(results)
+		result := CreateResult{JobKey: job.JobKey()}
+		deadline := r.clock.Now().Add(timeout)
+
+		result.Response, result.Err = r.CreateJob(job)
+		if result.Err == nil && result.Response.ResponseCode != aurora.ResponseCode_OK {
+			result.Err = errors.Errorf("Job rejected by Aurora Scheduler: %s", responseMessage(result.Response))
+		}
+
+		if result.Err == nil {
+			if job.jobConfig.TaskConfig.IsService {
+				result.Err = r.awaitRunningCount(job.JobKey(), int(job.jobConfig.InstanceCount), deadline)
+			} else {
+				result.Instances, result.Err = r.AwaitCompletion(job.JobKey(), deadline.Sub(r.clock.Now()))
+			}
+		}
+
+		results <- result
+	}()
+
+	return results
+}
+
+// Start an update and follow it in the background. The returned channel receives a single
+// result once the update reaches a terminal state or timeout expires.
+func (r *RealisClient) StartJobUpdateAsync(update *UpdateJob, message string, timeout time.Duration) <-chan UpdateResult {
+	results := make(chan UpdateResult, 1)
+
+	go func() {
+		defer close(results)
+		results <- r.runJobUpdate(update, message, timeout)
+	}()
+
+	return results
+}
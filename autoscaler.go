@@ -0,0 +1,253 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+// Returns the number of instances a job should run given how many it currently runs.
+type ScalingMetric func(key *aurora.JobKey, current int32) (int32, error)
+
+// Bounds and pacing of an autoscaler.
+type AutoscalerConfig struct {
+	Min int32
+	Max int32
+
+	// How often the metric is evaluated, every 5 seconds when zero.
+	Interval time.Duration
+
+	// Minimum time after any scaling action before scaling up or down again.
+	ScaleUpCooldown   time.Duration
+	ScaleDownCooldown time.Duration
+
+	// Time based bounds replacing Min and Max while active. The first matching profile wins.
+	Profiles []ScalingProfile
+
+	// Time zone profiles are evaluated in, defaults to UTC.
+	Location *time.Location
+
+	// Source of the current time, defaults to the client's clock.
+	Clock func() time.Time
+}
+
+// Bounds applied during a window of the day, e.g. at least 20 instances during business hours.
+// Set Min and Max to the same value to pin the job to a fixed number of instances.
+type ScalingProfile struct {
+	Name string
+
+	// Days the profile applies to, every day when empty.
+	Days []time.Weekday
+
+	// Window of the day as offsets from midnight. Windows where Start is after End wrap
+	// around midnight.
+	Start time.Duration
+	End   time.Duration
+
+	Min int32
+	Max int32
+}
+
+func (p ScalingProfile) active(now time.Time) bool {
+	return inWindow(now, p.Days, p.Start, p.End)
+}
+
+// Whether now falls in the daily window from start to end, on one of the given days or on
+// any day when none are given. Windows ending past midnight belong to the day they started on.
+func inWindow(now time.Time, days []time.Weekday, start, end time.Duration) bool {
+	offset := now.Sub(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()))
+	day := now.Weekday()
+
+	inside := offset >= start && offset < end
+	if start > end {
+		inside = offset >= start || offset < end
+		if offset < end {
+			day = (day + 6) % 7
+		}
+	}
+
+	if !inside {
+		return false
+	}
+
+	if len(days) == 0 {
+		return true
+	}
+
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Periodically evaluates a metric and adds or removes instances of a job to match it, within
+// the configured bounds, scaling profiles and cooldowns. The metric may be nil to only
+// follow the scaling profiles. Scaling actions and failures are published on the
+// client's event channel when it is a RealisClient.
+type Autoscaler struct {
+	client    Realis
+	key       *aurora.JobKey
+	metric    ScalingMetric
+	config    AutoscalerConfig
+	lastScale time.Time
+}
+
+func NewAutoscaler(client Realis, key *aurora.JobKey, metric ScalingMetric, config AutoscalerConfig) *Autoscaler {
+	if config.Interval <= 0 {
+		config.Interval = defaultPollInterval
+	}
+	return &Autoscaler{client: client, key: key, metric: metric, config: config}
+}
+
+// Evaluate the metric every interval until stop is closed.
+func (a *Autoscaler) Run(stop <-chan struct{}) {
+	for {
+		if _, err := a.Evaluate(); err != nil {
+			emitTo(a.client, Event{Type: AutoscaleErrorEvent, JobKey: a.key, Message: err.Error(), Payload: err})
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-clockOf(a.client).After(a.config.Interval):
+		}
+	}
+}
+
+// Evaluate the metric once and scale the job if needed, returning the change in instances.
+func (a *Autoscaler) Evaluate() (int32, error) {
+	instanceIds, err := activeInstanceIds(a.client, a.key)
+	if err != nil {
+		return 0, errors.Wrap(err, "Unable to retrieve active instances")
+	}
+	current := int32(len(instanceIds))
+
+	// Without a metric the job is only kept within the bounds of the active profile.
+	desired := current
+	if a.metric != nil {
+		desired, err = a.metric(a.key, current)
+		if err != nil {
+			return 0, errors.Wrap(err, "Error evaluating scaling metric")
+		}
+	}
+
+	desired = a.bound(desired)
+	delta := desired - current
+	if delta == 0 || !a.cooledDown(delta) {
+		return 0, nil
+	}
+
+	if delta > 0 {
+		if current == 0 {
+			return 0, errors.New("Unable to scale up a job without active instances to use as template")
+		}
+
+		_, err = a.client.AddInstances(&aurora.InstanceKey{JobKey: a.key, InstanceId: lowestInstanceId(instanceIds)}, delta)
+	} else {
+		_, err = a.client.RemoveInstances(a.key, -delta)
+	}
+
+	if err != nil {
+		return 0, errors.Wrapf(err, "Error scaling from %d to %d instances", current, desired)
+	}
+
+	a.lastScale = a.now()
+	emitTo(a.client, Event{
+		Type:    AutoscaleEvent,
+		JobKey:  a.key,
+		Message: fmt.Sprintf("Scaled from %d to %d instances", current, desired),
+		Payload: delta})
+
+	return delta, nil
+}
+
+func (a *Autoscaler) bound(desired int32) int32 {
+	min, max := a.config.Min, a.config.Max
+	if profile := a.ActiveProfile(); profile != nil {
+		min, max = profile.Min, profile.Max
+	}
+
+	if desired < min {
+		return min
+	}
+	if max > 0 && desired > max {
+		return max
+	}
+	return desired
+}
+
+// Profile currently in effect, nil if none applies.
+func (a *Autoscaler) ActiveProfile() *ScalingProfile {
+	location := a.config.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	now := a.now().In(location)
+	for i := range a.config.Profiles {
+		if a.config.Profiles[i].active(now) {
+			return &a.config.Profiles[i]
+		}
+	}
+	return nil
+}
+
+func (a *Autoscaler) cooledDown(delta int32) bool {
+	cooldown := a.config.ScaleDownCooldown
+	if delta > 0 {
+		cooldown = a.config.ScaleUpCooldown
+	}
+	return a.now().Sub(a.lastScale) >= cooldown
+}
+
+func (a *Autoscaler) now() time.Time {
+	if a.config.Clock != nil {
+		return a.config.Clock()
+	}
+	return clockOf(a.client).Now()
+}
+
+func lowestInstanceId(instanceIds map[int32]bool) int32 {
+	ids := make([]int32, 0, len(instanceIds))
+	for id := range instanceIds {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids[0]
+}
+
+// Instance ids of the active tasks of a job.
+func activeInstanceIds(client Realis, key *aurora.JobKey) (map[int32]bool, error) {
+	tasks, err := client.GetTasksWithoutConfigs(&aurora.TaskQuery{
+		Role:        key.Role,
+		Environment: key.Environment,
+		JobName:     key.Name,
+		Statuses:    aurora.ACTIVE_STATES})
+	if err != nil {
+		return nil, err
+	}
+
+	instanceIds := make(map[int32]bool)
+	for _, task := range tasks {
+		instanceIds[task.GetAssignedTask().GetInstanceId()] = true
+	}
+	return instanceIds, nil
+}
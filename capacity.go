@@ -0,0 +1,398 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// Resources and attributes of an offer currently held by the scheduler.
+type Offer struct {
+	Host       string
+	CPUs       float64
+	RamMb      float64
+	DiskMb     float64
+	Ports      int
+	Attributes map[string]string
+}
+
+type offerJSON struct {
+	Hostname  string `json:"hostname"`
+	Resources []struct {
+		Name   string `json:"name"`
+		Scalar *struct {
+			Value float64 `json:"value"`
+		} `json:"scalar"`
+		Ranges *struct {
+			Range []struct {
+				Begin int `json:"begin"`
+				End   int `json:"end"`
+			} `json:"range"`
+		} `json:"ranges"`
+	} `json:"resources"`
+	Attributes []struct {
+		Name string `json:"name"`
+		Text *struct {
+			Value string `json:"value"`
+		} `json:"text"`
+	} `json:"attributes"`
+}
+
+// Retrieve the offers the scheduler is holding from its /offers endpoint. Offers are the
+// free capacity of the cluster that new tasks can be placed on right away.
+func (r *RealisClient) Offers() ([]Offer, error) {
+	client := r.config.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Get(r.config.url + "/offers")
+	if err != nil {
+		return nil, errors.Wrap(err, "Error retrieving offers from Aurora Scheduler")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Offers endpoint returned %s", resp.Status)
+	}
+
+	var raw []offerJSON
+	if err := decodeJSON(resp.Body, &raw); err != nil {
+		return nil, errors.Wrap(err, "Error decoding offers")
+	}
+
+	offers := make([]Offer, 0, len(raw))
+	for _, o := range raw {
+		offer := Offer{Host: o.Hostname, Attributes: map[string]string{"host": o.Hostname}}
+
+		for _, resource := range o.Resources {
+			var value float64
+			if resource.Scalar != nil {
+				value = resource.Scalar.Value
+			}
+
+			switch resource.Name {
+			case "cpus":
+				offer.CPUs += value
+			case "mem":
+				offer.RamMb += value
+			case "disk":
+				offer.DiskMb += value
+			case "ports":
+				if resource.Ranges != nil {
+					for _, rng := range resource.Ranges.Range {
+						offer.Ports += rng.End - rng.Begin + 1
+					}
+				}
+			}
+		}
+
+		for _, attribute := range o.Attributes {
+			if attribute.Text != nil {
+				offer.Attributes[attribute.Name] = attribute.Text.Value
+			}
+		}
+
+		offers = append(offers, offer)
+	}
+
+	return offers, nil
+}
+
+// Estimate how many of count new instances of a job fit in the offers currently held by the
+// scheduler, honoring the job's resources, value constraints and limit constraints. Instances
+// are configured like the job's running tasks. Capacity freed by tasks that are about to
+// finish is not counted, so the estimate errs on the low side.
+func (r *RealisClient) ScaleOutCapacity(key *aurora.JobKey, count int32) (int32, error) {
+	tasks, err := r.GetTaskStatus(&aurora.TaskQuery{
+		Role:        key.Role,
+		Environment: key.Environment,
+		JobName:     key.Name,
+		Statuses:    aurora.ACTIVE_STATES})
+	if err != nil {
+		return 0, errors.Wrap(err, "Unable to retrieve active tasks")
+	}
+
+	if len(tasks) == 0 {
+		return 0, errors.New("No active tasks to take the job configuration from")
+	}
+
+	offers, err := r.Offers()
+	if err != nil {
+		return 0, err
+	}
+
+	config := tasks[0].AssignedTask.Task
+	cpus, ramMb, diskMb := taskResources(config)
+	ports := taskPortCount(config)
+
+	hostAttributes := make(map[string]map[string]string)
+	for _, offer := range offers {
+		hostAttributes[offer.Host] = offer.Attributes
+	}
+
+	// Instances already placed per limit constraint and attribute value.
+	placed := make(map[string]map[string]int32)
+	for constraint := range config.Constraints {
+		if constraint.Constraint != nil && constraint.Constraint.Limit != nil {
+			placed[constraint.Name] = make(map[string]int32)
+		}
+	}
+	for _, task := range tasks {
+		host := task.AssignedTask.SlaveHost
+		if host == "" {
+			continue
+		}
+
+		attributes := hostAttributes[host]
+		for name, values := range placed {
+			if name == "host" {
+				values[host]++
+			} else if value, ok := attributes[name]; ok {
+				values[value]++
+			}
+		}
+	}
+
+	var fit int32
+	for _, offer := range offers {
+		if fit >= count {
+			break
+		}
+
+		n := count - fit
+		n = minFit(n, offer.CPUs, cpus)
+		n = minFit(n, offer.RamMb, float64(ramMb))
+		n = minFit(n, offer.DiskMb, float64(diskMb))
+		n = minFit(n, float64(offer.Ports), float64(ports))
+
+		for constraint := range config.Constraints {
+			if n == 0 {
+				break
+			}
+			if constraint.Constraint == nil {
+				continue
+			}
+
+			value, ok := offer.Attributes[constraint.Name]
+			if v := constraint.Constraint.Value; v != nil {
+				if matches := ok && v.Values[value]; matches == v.Negated {
+					n = 0
+				}
+			}
+			if l := constraint.Constraint.Limit; l != nil && ok {
+				room := l.Limit - placed[constraint.Name][value]
+				if room < 0 {
+					room = 0
+				}
+				if room < n {
+					n = room
+				}
+			}
+		}
+
+		if n == 0 {
+			continue
+		}
+
+		for constraint := range config.Constraints {
+			if constraint.Constraint != nil && constraint.Constraint.Limit != nil {
+				if value, ok := offer.Attributes[constraint.Name]; ok {
+					placed[constraint.Name][value] += n
+				}
+			}
+		}
+		fit += n
+	}
+
+	return fit, nil
+}
+
+// Add as many of count instances as fit in the cluster right now. Returns the number of
+// instances added along with the scheduler's response, which is nil if none fit.
+func (r *RealisClient) ScaleOut(instKey *aurora.InstanceKey, count int32) (int32, *aurora.Response, error) {
+	if err := ValidateInstanceKey(instKey); err != nil {
+		return 0, nil, err
+	}
+
+	fit, err := r.ScaleOutCapacity(instKey.JobKey, count)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if fit == 0 {
+		return 0, nil, nil
+	}
+
+	response, err := r.AddInstances(instKey, fit)
+	if err != nil {
+		return 0, response, err
+	}
+
+	return fit, response, nil
+}
+
+// Lower n to the number of tasks needing perTask of a resource that fit in available.
+func minFit(n int32, available float64, perTask float64) int32 {
+	if perTask <= 0 {
+		return n
+	}
+
+	if fits := int32(available / perTask); fits < n {
+		return fits
+	}
+	return n
+}
+
+// Number of ports requested by a task.
+func taskPortCount(config *aurora.TaskConfig) int {
+	count := 0
+	for resource := range config.Resources {
+		if resource.NamedPort != nil {
+			count++
+		}
+	}
+
+	if count == 0 {
+		count = len(config.RequestedPorts)
+	}
+	return count
+}
+
+// Size of the instances EstimateHeadroom estimates room for. Quota is only checked for
+// production instances of a role, as the scheduler does.
+type ResourceProfile struct {
+	Role       string
+	Production bool
+	CPU        float64
+	RamMb      int64
+	DiskMb     int64
+	Ports      int
+}
+
+// How many more instances of a profile would likely be scheduled right now.
+type HeadroomEstimate struct {
+	Profile ResourceProfile
+
+	// Instances fitting in the offers left once tasks pending for lack of resources, which
+	// the scheduler places first, have claimed theirs.
+	OfferFit int32
+
+	// Instances fitting in the role's remaining production quota, -1 when quota does not apply.
+	QuotaFit int32
+
+	// Tasks pending because no offer had enough resources for them.
+	StarvedTasks int
+
+	// Lower of OfferFit and QuotaFit, along with which of "offers" or "quota" it comes from.
+	Instances int32
+	LimitedBy string
+}
+
+// Estimate how many more instances of the given size would schedule, for sanity checks before
+// scaling out. Tasks the scheduler reports as pending with insufficient resources are placed
+// on the offers first, and production instances are capped by the role's remaining quota.
+// Constraints are ignored, see ScaleOutCapacity for an estimate honoring a job's constraints.
+func (r *RealisClient) EstimateHeadroom(profile ResourceProfile) (*HeadroomEstimate, error) {
+	if profile.CPU <= 0 && profile.RamMb <= 0 && profile.DiskMb <= 0 {
+		return nil, errors.New("Resource profile requests no resources")
+	}
+
+	offers, err := r.Offers()
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := r.pendingTasks(&aurora.TaskQuery{}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &HeadroomEstimate{Profile: profile, QuotaFit: -1}
+	for _, task := range pending {
+		if config := task.task.AssignedTask.Task; config != nil && starved(task.reasons) {
+			estimate.StarvedTasks++
+			claimOffer(offers, config)
+		}
+	}
+
+	for _, offer := range offers {
+		n := int32(math.MaxInt32)
+		n = minFit(n, offer.CPUs, profile.CPU)
+		n = minFit(n, offer.RamMb, float64(profile.RamMb))
+		n = minFit(n, offer.DiskMb, float64(profile.DiskMb))
+		n = minFit(n, float64(offer.Ports), float64(profile.Ports))
+		estimate.OfferFit += n
+	}
+
+	estimate.Instances, estimate.LimitedBy = estimate.OfferFit, "offers"
+
+	if profile.Production && profile.Role != "" {
+		quota, err := r.GetQuota(profile.Role)
+		if err != nil {
+			return nil, err
+		}
+
+		if quota.Quota != nil {
+			used := quota.ProdSharedConsumption
+			if used == nil {
+				used = aurora.NewResourceAggregate()
+			}
+
+			n := int32(math.MaxInt32)
+			n = minFit(n, math.Max(quota.Quota.NumCpus-used.NumCpus, 0), profile.CPU)
+			n = minFit(n, math.Max(float64(quota.Quota.RamMb-used.RamMb), 0), float64(profile.RamMb))
+			n = minFit(n, math.Max(float64(quota.Quota.DiskMb-used.DiskMb), 0), float64(profile.DiskMb))
+			estimate.QuotaFit = n
+
+			if n < estimate.Instances {
+				estimate.Instances, estimate.LimitedBy = n, "quota"
+			}
+		}
+	}
+
+	return estimate, nil
+}
+
+// Whether the scheduler vetoed a pending task for lack of resources, e.g. "Insufficient: CPU".
+func starved(reasons []string) bool {
+	for _, reason := range reasons {
+		if strings.HasPrefix(reason, "Insufficient") {
+			return true
+		}
+	}
+	return false
+}
+
+// Take the resources of a task from the first offer they fit in.
+func claimOffer(offers []Offer, config *aurora.TaskConfig) {
+	cpus, ramMb, diskMb := taskResources(config)
+	ports := taskPortCount(config)
+
+	for i := range offers {
+		offer := &offers[i]
+		if offer.CPUs >= cpus && offer.RamMb >= float64(ramMb) && offer.DiskMb >= float64(diskMb) && offer.Ports >= ports {
+			offer.CPUs -= cpus
+			offer.RamMb -= float64(ramMb)
+			offer.DiskMb -= float64(diskMb)
+			offer.Ports -= ports
+			return
+		}
+	}
+}
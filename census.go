@@ -0,0 +1,170 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+// Resources requested by a set of instances.
+type ResourceTotals struct {
+	CPU    float64 `json:"cpu"`
+	RamMb  int64   `json:"ram_mb"`
+	DiskMb int64   `json:"disk_mb"`
+}
+
+func (t *ResourceTotals) add(other ResourceTotals) {
+	t.CPU += other.CPU
+	t.RamMb += other.RamMb
+	t.DiskMb += other.DiskMb
+}
+
+// Job as recorded in a census.
+type CensusJob struct {
+	Role          string     `json:"role"`
+	Environment   string     `json:"environment"`
+	Name          string     `json:"name"`
+	CronSchedule  string     `json:"cron_schedule,omitempty"`
+	NextCronRun   *time.Time `json:"next_cron_run,omitempty"`
+	InstanceCount int32      `json:"instance_count"`
+	ActiveTasks   int32      `json:"active_tasks"`
+	PendingTasks  int32      `json:"pending_tasks"`
+
+	// Resources of a single instance and of all configured instances.
+	PerInstance ResourceTotals `json:"per_instance"`
+	Resources   ResourceTotals `json:"resources"`
+}
+
+// Jobs owned by a role.
+type CensusRole struct {
+	Role         string         `json:"role"`
+	JobCount     int32          `json:"job_count"`
+	CronJobCount int32          `json:"cron_job_count"`
+	Jobs         []CensusJob    `json:"jobs"`
+	Resources    ResourceTotals `json:"resources"`
+}
+
+// Inventory of every role and job known to the scheduler, meant to be serialized as a
+// periodic snapshot. Roles and jobs are sorted by name.
+type Census struct {
+	Time      time.Time      `json:"time"`
+	Roles     []CensusRole   `json:"roles"`
+	Jobs      int            `json:"jobs"`
+	CronJobs  int            `json:"cron_jobs"`
+	Instances int32          `json:"instances"`
+	Resources ResourceTotals `json:"resources"`
+}
+
+// Gather every role, job and cron job with their instance counts and requested resources.
+func (r *RealisClient) Census() (*Census, error) {
+	response, err := r.thriftCall("GetRoleSummary", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetRoleSummary()
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for roles")
+	}
+
+	census := &Census{Time: r.clock.Now()}
+	if response.Result_ == nil || response.Result_.RoleSummaryResult_ == nil {
+		return census, nil
+	}
+
+	for summary := range response.Result_.RoleSummaryResult_.Summaries {
+		role, err := r.censusRole(summary)
+		if err != nil {
+			return nil, err
+		}
+
+		census.Roles = append(census.Roles, *role)
+		census.Resources.add(role.Resources)
+		for _, job := range role.Jobs {
+			census.Jobs++
+			if job.CronSchedule != "" {
+				census.CronJobs++
+			}
+			census.Instances += job.InstanceCount
+		}
+	}
+
+	sort.Slice(census.Roles, func(i, j int) bool { return census.Roles[i].Role < census.Roles[j].Role })
+	return census, nil
+}
+
+func (r *RealisClient) censusRole(summary *aurora.RoleSummary) (*CensusRole, error) {
+	response, err := r.thriftCall("GetJobSummary", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetJobSummary(summary.Role)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error querying Aurora Scheduler for jobs of role %s", summary.Role)
+	}
+
+	role := &CensusRole{Role: summary.Role, JobCount: summary.JobCount, CronJobCount: summary.CronJobCount}
+	if response.Result_ == nil || response.Result_.JobSummaryResult_ == nil {
+		return role, nil
+	}
+
+	for jobSummary := range response.Result_.JobSummaryResult_.Summaries {
+		if jobSummary.Job == nil || jobSummary.Job.Key == nil {
+			continue
+		}
+
+		job := newCensusJob(jobSummary)
+		role.Jobs = append(role.Jobs, job)
+		role.Resources.add(job.Resources)
+	}
+
+	sort.Slice(role.Jobs, func(i, j int) bool {
+		a, b := role.Jobs[i], role.Jobs[j]
+		if a.Environment != b.Environment {
+			return a.Environment < b.Environment
+		}
+		return a.Name < b.Name
+	})
+
+	return role, nil
+}
+
+func newCensusJob(summary *aurora.JobSummary) CensusJob {
+	config := summary.Job
+	job := CensusJob{
+		Role:          config.Key.Role,
+		Environment:   config.Key.Environment,
+		Name:          config.Key.Name,
+		InstanceCount: config.InstanceCount}
+
+	if config.CronSchedule != nil {
+		job.CronSchedule = *config.CronSchedule
+	}
+	if summary.NextCronRunMs != nil {
+		next := msToTime(*summary.NextCronRunMs)
+		job.NextCronRun = &next
+	}
+	if summary.Stats != nil {
+		job.ActiveTasks = summary.Stats.ActiveTaskCount
+		job.PendingTasks = summary.Stats.PendingTaskCount
+	}
+
+	cpus, ramMb, diskMb := taskResources(config.TaskConfig)
+	job.PerInstance = ResourceTotals{CPU: cpus, RamMb: ramMb, DiskMb: diskMb}
+	job.Resources = ResourceTotals{
+		CPU:    cpus * float64(config.InstanceCount),
+		RamMb:  ramMb * int64(config.InstanceCount),
+		DiskMb: diskMb * int64(config.InstanceCount)}
+
+	return job
+}
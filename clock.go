@@ -0,0 +1,58 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import "time"
+
+// Source of time for everything in the client that waits or reads the time: monitors, waiting
+// functions, the watchdog and the autoscaler. Tests can replace it with a fake clock to run
+// deterministically without real sleeps.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+}
+
+// Clock backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// Replace the clock used by the client. Should be set before the client is shared between
+// goroutines. A nil clock restores the real one.
+func (r *RealisClient) SetClock(clock Clock) {
+	if clock == nil {
+		clock = realClock{}
+	}
+	r.clock = clock
+}
+
+// Clock of a client, the real clock for implementations of Realis other than RealisClient.
+func clockOf(client Realis) Clock {
+	if r, ok := client.(*RealisClient); ok {
+		return r.clock
+	}
+	return realClock{}
+}
@@ -0,0 +1,67 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Clock that only moves when slept on or waited for, recording every wait.
+type fakeClock struct {
+	lock  sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.advance(d)
+	return ch
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.advance(d)
+}
+
+func (c *fakeClock) advance(d time.Duration) time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+	c.waits = append(c.waits, d)
+	return c.now
+}
+
+// Client over an in-memory transport, for tests supplying their own rpcFuncs.
+func newTestClient(t *testing.T, clock Clock) *RealisClient {
+	r, err := NewClient(NewConfigWithTransport(thrift.NewTMemoryBuffer()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetClock(clock)
+	return r
+}
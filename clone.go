@@ -0,0 +1,149 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"bytes"
+	"encoding/json"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// Fields replaced when cloning a job. Zero values keep the source job's setting.
+type CloneOverrides struct {
+	Name          string
+	Environment   string
+	InstanceCount int32
+
+	// Container image, e.g. registry/app:debug. Replaces the Docker image of Docker containers and
+	// sets a Docker image on Mesos containers.
+	Image string
+}
+
+// Create a copy of an existing job with some fields overridden, e.g. a single instance debug
+// copy with another name. The new job must have a different key than the source. Clones of
+// cron jobs are scheduled rather than created.
+func (r *RealisClient) CloneJob(source *aurora.JobKey, overrides CloneOverrides) (*Job, error) {
+	configs, err := r.GetJobs(source.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	config := findJobConfig(configs, source)
+	if config == nil {
+		return nil, errors.Errorf("Job %s/%s/%s not found", source.Role, source.Environment, source.Name)
+	}
+
+	key := &aurora.JobKey{Role: source.Role, Environment: source.Environment, Name: source.Name}
+	if overrides.Name != "" {
+		key.Name = overrides.Name
+	}
+	if overrides.Environment != "" {
+		key.Environment = overrides.Environment
+	}
+	if *key == *source {
+		return nil, errors.New("Clone must have a different name or environment than the source job")
+	}
+
+	// The fetched configuration is ours to modify, only the key is shared with the task config.
+	config.Key = key
+	config.TaskConfig.Job = key
+	if err := rekeyThermosPayload(config.TaskConfig); err != nil {
+		return nil, err
+	}
+
+	job := NewJobFromConfig(config)
+
+	if overrides.InstanceCount > 0 {
+		job.InstanceCount(overrides.InstanceCount)
+	}
+
+	if overrides.Image != "" {
+		setImage(config.TaskConfig, overrides.Image)
+	}
+
+	var response *aurora.Response
+	if job.IsCron() {
+		response, err = r.scheduleCronJob(job)
+	} else {
+		response, err = r.CreateJob(job)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to create clone")
+	}
+	if response.ResponseCode != aurora.ResponseCode_OK {
+		return nil, errors.Errorf("Clone rejected by Aurora Scheduler: %s", responseMessage(response))
+	}
+
+	return job, nil
+}
+
+func setImage(taskConfig *aurora.TaskConfig, image string) {
+	if taskConfig.Container == nil {
+		taskConfig.Container = aurora.NewContainer()
+	}
+
+	if taskConfig.Container.Docker != nil {
+		taskConfig.Container.Docker.Image = image
+		return
+	}
+
+	name, tag := image, "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name, tag = image[:i], image[i+1:]
+	}
+
+	if taskConfig.Container.Mesos == nil {
+		taskConfig.Container.Mesos = aurora.NewMesosContainer()
+	}
+	taskConfig.Container.Mesos.Image = &aurora.Image{Docker: &aurora.DockerImage{Name: name, Tag: tag}}
+}
+
+// Point the Thermos payload of a task copied from another job at the task's own key, Thermos
+// reads the job's name and environment from it. Payloads of other executors are left alone.
+func rekeyThermosPayload(taskConfig *aurora.TaskConfig) error {
+	executor := taskConfig.ExecutorConfig
+	if executor == nil || executor.Name != aurora.AURORA_EXECUTOR_NAME || executor.Data == "" {
+		return nil
+	}
+
+	// Numbers are kept as written, e.g. resources in bytes.
+	payload := make(map[string]interface{})
+	decoder := json.NewDecoder(strings.NewReader(executor.Data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return errors.Wrap(err, "Error parsing Thermos payload")
+	}
+
+	for field, value := range map[string]string{
+		"role":        taskConfig.Job.Role,
+		"environment": taskConfig.Job.Environment,
+		"name":        taskConfig.Job.Name} {
+		if _, ok := payload[field]; ok {
+			payload[field] = value
+		}
+	}
+
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(payload); err != nil {
+		return errors.Wrap(err, "Error serializing Thermos payload")
+	}
+
+	executor.Data = strings.TrimSuffix(data.String(), "\n")
+	return nil
+}
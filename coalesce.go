@@ -0,0 +1,97 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Coalesces identical concurrent queries into a single in-flight RPC whose result is shared by
+// every caller, cutting the load many monitors put on the scheduler.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	wg       sync.WaitGroup
+	response *aurora.Response
+	err      error
+}
+
+// Run fn unless a call with the same key is in flight, in which case wait for its result.
+func (g *flightGroup) do(key string, fn func() (*aurora.Response, error)) (*aurora.Response, error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	if f, ok := g.flights[key]; ok {
+		g.mu.Unlock()
+		f.wg.Wait()
+		return f.response, f.err
+	}
+
+	f := &flight{}
+	f.wg.Add(1)
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	f.response, f.err = fn()
+	f.wg.Done()
+
+	g.mu.Lock()
+	delete(g.flights, key)
+	g.mu.Unlock()
+
+	return f.response, f.err
+}
+
+// Canonical representation of a task query, equal for queries matching the same tasks.
+func taskQueryKey(query *aurora.TaskQuery) string {
+	if query == nil {
+		return ""
+	}
+
+	var taskIds, statuses, instanceIds, hosts, jobKeys []string
+	for id := range query.TaskIds {
+		taskIds = append(taskIds, id)
+	}
+	for status := range query.Statuses {
+		statuses = append(statuses, status.String())
+	}
+	for id := range query.InstanceIds {
+		instanceIds = append(instanceIds, fmt.Sprint(id))
+	}
+	for host := range query.SlaveHosts {
+		hosts = append(hosts, host)
+	}
+	for key := range query.JobKeys {
+		jobKeys = append(jobKeys, key.Role+"/"+key.Environment+"/"+key.Name)
+	}
+
+	join := func(values []string) string {
+		sort.Strings(values)
+		return strings.Join(values, ",")
+	}
+
+	return fmt.Sprintf("%s/%s/%s|%s|%s|%s|%s|%s|%d|%d",
+		query.Role, query.Environment, query.JobName,
+		join(taskIds), join(statuses), join(instanceIds), join(hosts), join(jobKeys),
+		query.Offset, query.Limit)
+}
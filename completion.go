@@ -0,0 +1,102 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+// How an instance of a batch job ended.
+type InstanceResult struct {
+	InstanceID int32
+	Status     aurora.ScheduleStatus
+
+	// Message of the final status change, e.g. the reason the task failed.
+	Message string
+
+	// Latest terminal task of the instance.
+	Task *Task
+}
+
+// Whether the instance completed successfully.
+func (i InstanceResult) Succeeded() bool {
+	return i.Status == aurora.ScheduleStatus_FINISHED
+}
+
+// Wait for every instance of a batch job to end and return how each of them ended, ordered by
+// instance id. Instances that are retried by the scheduler are only done once they have no
+// active task left.
+func (r *RealisClient) AwaitCompletion(key *aurora.JobKey, timeout time.Duration) ([]InstanceResult, error) {
+	deadline := r.clock.Now().Add(timeout)
+	poll := r.newPoller()
+
+	for {
+		tasks, err := r.GetTasksWithoutConfigs(&aurora.TaskQuery{
+			Role:        key.Role,
+			Environment: key.Environment,
+			JobName:     key.Name})
+		if err != nil {
+			return nil, err
+		}
+
+		final := make(map[int32]*aurora.ScheduledTask)
+		active := make(map[int32]bool)
+		for _, task := range tasks {
+			instanceId := task.AssignedTask.InstanceId
+			if IsActive(task.Status) {
+				active[instanceId] = true
+			} else if last, ok := final[instanceId]; !ok || lastEventMs(task) > lastEventMs(last) {
+				final[instanceId] = task
+			}
+		}
+
+		if len(active) == 0 && len(final) > 0 {
+			return instanceResults(final), nil
+		}
+
+		wait := poll.next(len(active))
+		if r.clock.Now().Add(wait).After(deadline) {
+			return nil, errors.Errorf("Timed out with %d instances of %s/%s/%s still active",
+				len(active), key.Role, key.Environment, key.Name)
+		}
+		r.clock.Sleep(wait)
+	}
+}
+
+func instanceResults(final map[int32]*aurora.ScheduledTask) []InstanceResult {
+	results := make([]InstanceResult, 0, len(final))
+	for instanceId, task := range final {
+		result := InstanceResult{InstanceID: instanceId, Status: task.Status, Task: NewTask(task)}
+		if len(task.TaskEvents) > 0 {
+			if message := task.TaskEvents[len(task.TaskEvents)-1].Message; message != nil {
+				result.Message = *message
+			}
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].InstanceID < results[j].InstanceID })
+	return results
+}
+
+func lastEventMs(task *aurora.ScheduledTask) int64 {
+	if len(task.TaskEvents) == 0 {
+		return 0
+	}
+	return task.TaskEvents[len(task.TaskEvents)-1].Timestamp
+}
@@ -0,0 +1,119 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Ask the scheduler for gzip compressed responses and decompress them transparently, cutting
+// the transfer of big task queries by an order of magnitude. With gzipRequests set, request
+// bodies are compressed too, which only pays off for large mutations such as job updates and
+// needs a scheduler, or proxy in front of it, accepting compressed requests. Thrift limits
+// apply to the decompressed responses.
+func WithCompression(config *RealisConfig, gzipRequests bool) error {
+	if config.httpClient == nil {
+		return errors.New("Compression requires an HTTP transport")
+	}
+
+	next := config.httpClient.Transport
+	if next == nil {
+		base, err := baseTransport(config)
+		if err != nil {
+			return err
+		}
+		next = base
+	}
+
+	config.httpClient.Transport = &gzipTransport{next: next, gzipRequests: gzipRequests}
+	return nil
+}
+
+type gzipTransport struct {
+	next         http.RoundTripper
+	gzipRequests bool
+}
+
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests are not modified in place, as RoundTrippers must not.
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	if t.gzipRequests && req.Body != nil && req.Header.Get("Content-Encoding") == "" {
+		body, err := gzipBody(req.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.ContentLength = int64(len(body))
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.Header.Get("Content-Encoding") != "gzip" {
+		return resp, err
+	}
+
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return nil, errors.Wrap(err, "Error decompressing scheduler response")
+	}
+
+	resp.Body = &gunzipBody{reader: reader, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+func gzipBody(body io.ReadCloser) ([]byte, error) {
+	defer body.Close()
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := io.Copy(writer, body); err != nil {
+		return nil, errors.Wrap(err, "Error compressing request")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "Error compressing request")
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompressed response body, closing the underlying body along with the gzip reader.
+type gunzipBody struct {
+	reader *gzip.Reader
+	body   io.ReadCloser
+}
+
+func (b *gunzipBody) Read(p []byte) (int, error) {
+	return b.reader.Read(p)
+}
+
+func (b *gunzipBody) Close() error {
+	b.reader.Close()
+	return b.body.Close()
+}
@@ -0,0 +1,133 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"gen-go/apache/aurora"
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"net"
+	"net/url"
+	"strings"
+)
+
+type ConnectionProblem string
+
+const (
+	DNSProblem       ConnectionProblem = "DNS"
+	NetworkProblem   ConnectionProblem = "NETWORK"
+	TLSProblem       ConnectionProblem = "TLS"
+	AuthProblem      ConnectionProblem = "AUTH"
+	ProtocolProblem  ConnectionProblem = "PROTOCOL"
+	SchedulerProblem ConnectionProblem = "SCHEDULER"
+	UnknownProblem   ConnectionProblem = "UNKNOWN"
+)
+
+// Returned by VerifyConnection, pointing at the layer where communication broke down
+// together with a hint on how to fix it.
+type ConnectionError struct {
+	Problem ConnectionProblem
+	Hint    string
+	Err     error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("%s problem: %v (%s)", e.Problem, e.Err, e.Hint)
+}
+
+func (e *ConnectionError) Cause() error {
+	return e.Err
+}
+
+// Perform an end to end read call against the scheduler using the configured transport and
+// credentials. Returns a *ConnectionError describing what went wrong when the call fails.
+func (r *RealisClient) VerifyConnection() error {
+	if r.config.url != "" {
+		if parsed, err := url.Parse(r.config.url); err == nil {
+			if _, err := net.LookupHost(parsed.Hostname()); err != nil {
+				return &ConnectionError{
+					Problem: DNSProblem,
+					Hint:    "check that the scheduler host name is correct and resolvable",
+					Err:     err}
+			}
+		}
+	}
+
+	response, err := r.thriftCall("GetRoleSummary", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetRoleSummary()
+	})
+	if err != nil {
+		return diagnoseConnectionError(err)
+	}
+
+	switch response.ResponseCode {
+	case aurora.ResponseCode_OK:
+		return nil
+	case aurora.ResponseCode_AUTH_FAILED:
+		return &ConnectionError{
+			Problem: AuthProblem,
+			Hint:    "check the credentials configured for the client",
+			Err:     fmt.Errorf("%s", responseMessage(response))}
+	default:
+		return &ConnectionError{
+			Problem: SchedulerProblem,
+			Hint:    "the scheduler is reachable but could not serve the request",
+			Err:     fmt.Errorf("%v: %s", response.ResponseCode, responseMessage(response))}
+	}
+}
+
+func diagnoseConnectionError(err error) *ConnectionError {
+	for _, cause := range errorChain(err) {
+		switch cause.(type) {
+		case *net.DNSError:
+			return &ConnectionError{DNSProblem, "check that the scheduler host name is correct and resolvable", err}
+		case x509.UnknownAuthorityError, x509.CertificateInvalidError, x509.HostnameError,
+			tls.RecordHeaderError, *tls.CertificateVerificationError:
+			return &ConnectionError{TLSProblem, "check the scheduler certificate and the trusted CAs, or the URL scheme", err}
+		case thrift.TTransportException:
+			// Transport exceptions also satisfy TProtocolException, they are told apart below
+			// by their HTTP status or as network failures.
+		case thrift.TProtocolException:
+			return &ConnectionError{ProtocolProblem, "the endpoint did not answer with the expected thrift protocol, check the API path and protocol", err}
+		case *ThriftLimitError:
+			return &ConnectionError{ProtocolProblem, "the response is larger than the configured thrift limits allow", err}
+		case *RedirectLoopError:
+			return &ConnectionError{ProtocolProblem, "check the proxies in front of the schedulers and the URL", err}
+		case thrift.TApplicationException:
+			return &ConnectionError{SchedulerProblem, "the scheduler failed to process the call", err}
+		}
+	}
+
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "HTTP Response code: 401"), strings.Contains(message, "HTTP Response code: 403"):
+		return &ConnectionError{AuthProblem, "check the credentials configured for the client", err}
+	case strings.Contains(message, "HTTP Response code: 404"):
+		return &ConnectionError{ProtocolProblem, "no API found at the configured path", err}
+	case strings.Contains(message, "HTTP Response code"):
+		return &ConnectionError{SchedulerProblem, "the scheduler answered with an unexpected HTTP status", err}
+	}
+
+	for _, cause := range errorChain(err) {
+		switch cause.(type) {
+		case net.Error, thrift.TTransportException:
+			return &ConnectionError{NetworkProblem, "check that the scheduler is running and reachable from this host", err}
+		}
+	}
+
+	return &ConnectionError{UnknownProblem, "see the underlying error", err}
+}
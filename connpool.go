@@ -0,0 +1,177 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/pkg/errors"
+)
+
+// Connections opened by default for calls made concurrently.
+const defaultMaxConnections = 8
+
+// Headers copied from the configured transport to the transports of pooled connections.
+var pooledHeaders = []string{"User-Agent", "Authorization"}
+
+// Thrift clients over a transport of their own. Transports and clients keep per call state,
+// so a connection is used by a single call at a time.
+type connection struct {
+	transport   thrift.TTransport
+	client      *aurora.AuroraSchedulerManagerClient
+	adminClient *aurora.AuroraAdminClient
+	generation  int
+
+	// Set when thrift limits are configured.
+	limits *limitedTransport
+}
+
+// Connections shared by the goroutines using a client. HTTP connections underneath are
+// reused across them by the client's http.Client.
+type connectionPool struct {
+	slots      chan struct{}
+	idle       []*connection
+	generation int
+	closed     bool
+}
+
+func newConnectionPool(size int) *connectionPool {
+	return &connectionPool{slots: make(chan struct{}, size)}
+}
+
+// Let up to n calls be made concurrently, each over its own connection. Clients created
+// around a transport other than HTTP, e.g. with NewConfigWithTransport, always make one call
+// at a time. Should be set before the client is shared between goroutines.
+func (r *RealisClient) SetMaxConnections(n int) error {
+	if n <= 0 {
+		return errors.New("Maximum number of connections must be greater than 0")
+	}
+
+	if !r.pooled() {
+		n = 1
+	}
+	r.pool = newConnectionPool(n)
+	return nil
+}
+
+// Whether connections can be opened, as opposed to sharing the configured transport.
+func (r *RealisClient) pooled() bool {
+	_, ok := r.config.transport.(*thrift.THttpClient)
+	return ok && r.config.httpClient != nil
+}
+
+// Take a connection, waiting for one if as many as allowed are in use.
+func (r *RealisClient) acquire() (*connection, error) {
+	pool := r.pool
+	pool.slots <- struct{}{}
+
+	r.poolLock.Lock()
+	defer r.poolLock.Unlock()
+
+	if pool.closed {
+		<-pool.slots
+		return nil, errors.New("Client is closed")
+	}
+
+	if n := len(pool.idle); n > 0 {
+		conn := pool.idle[n-1]
+		pool.idle = pool.idle[:n-1]
+		return conn, nil
+	}
+
+	conn, err := r.connect()
+	if err != nil {
+		<-pool.slots
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Give a connection back. Connections whose last call failed, or opened before the client
+// reconnected to another scheduler, are closed instead of reused.
+func (r *RealisClient) release(conn *connection, failed bool) {
+	pool := r.pool
+	defer func() { <-pool.slots }()
+
+	r.poolLock.Lock()
+	defer r.poolLock.Unlock()
+
+	if failed || pool.closed || conn.generation != pool.generation {
+		r.closeConnection(conn)
+		return
+	}
+	pool.idle = append(pool.idle, conn)
+}
+
+// Open a connection to the current scheduler. Pooled connections get a transport of their
+// own carrying the headers of the configured one, others share the configured transport.
+// Must be called with poolLock held.
+func (r *RealisClient) connect() (*connection, error) {
+	trans := r.config.transport
+	if r.pooled() {
+		var err error
+		if trans, err = r.newTransport(r.config.url); err != nil {
+			return nil, err
+		}
+	}
+
+	conn := &connection{transport: trans, generation: r.pool.generation}
+
+	protocolFactory := r.config.protocol.factory()
+	if r.config.limits != (ThriftLimits{}) {
+		conn.limits = &limitedTransport{TTransport: trans, limits: r.config.limits}
+		protocolFactory = &limitedProtocolFactory{factory: protocolFactory, transport: conn.limits}
+		trans = conn.limits
+	}
+
+	conn.client = aurora.NewAuroraSchedulerManagerClientFactory(trans, protocolFactory)
+	conn.adminClient = aurora.NewAuroraAdminClientFactory(trans, protocolFactory)
+	return conn, nil
+}
+
+// Create a transport to the scheduler at url with the headers of the configured transport.
+func (r *RealisClient) newTransport(url string) (thrift.TTransport, error) {
+	trans, err := thrift.NewTHttpPostClientWithOptions(url+r.config.apiPath,
+		thrift.THttpClientOptions{Client: r.config.httpClient})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error creating transport.")
+	}
+
+	if template, ok := r.config.transport.(headerEditor); ok {
+		for _, header := range pooledHeaders {
+			if value := template.GetHeader(header); value != "" {
+				trans.(headerSetter).SetHeader(header, value)
+			}
+		}
+	}
+	r.config.protocol.setHeaders(trans.(headerEditor))
+	return trans, nil
+}
+
+// Drop the idle connections and have the ones in use closed when released, e.g. after
+// switching to another scheduler. Must be called with poolLock held.
+func (r *RealisClient) resetConnections() {
+	for _, conn := range r.pool.idle {
+		r.closeConnection(conn)
+	}
+	r.pool.idle = nil
+	r.pool.generation++
+}
+
+func (r *RealisClient) closeConnection(conn *connection) {
+	if conn.transport != r.config.transport {
+		conn.transport.Close()
+	}
+}
@@ -0,0 +1,91 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// Replace the cookie jar used by the configuration, e.g. with a jar shared across clients.
+func SetCookieJar(config *RealisConfig, jar http.CookieJar) error {
+	if config.httpClient == nil {
+		return errors.New("Configuration does not use an HTTP transport")
+	}
+
+	config.httpClient.Jar = jar
+	return nil
+}
+
+// Persist the session cookies obtained from the scheduler so that short-lived invocations can
+// reuse an authenticated session instead of logging in every run.
+func SaveCookies(config *RealisConfig, path string) error {
+	schedulerURL, jar, err := cookieTarget(config)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(jar.Cookies(schedulerURL))
+	if err != nil {
+		return errors.Wrap(err, "Error serializing cookies")
+	}
+
+	// Cookies may hold session credentials, keep them private to the user.
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return errors.Wrapf(err, "Error writing cookies to %s", path)
+	}
+
+	return nil
+}
+
+// Restore session cookies previously written by SaveCookies. A missing file is not an error.
+func LoadCookies(config *RealisConfig, path string) error {
+	schedulerURL, jar, err := cookieTarget(config)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "Error reading cookies from %s", path)
+	}
+
+	var cookies []*http.Cookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return errors.Wrapf(err, "Error parsing cookies from %s", path)
+	}
+
+	jar.SetCookies(schedulerURL, cookies)
+	return nil
+}
+
+func cookieTarget(config *RealisConfig) (*url.URL, http.CookieJar, error) {
+	if config.httpClient == nil || config.httpClient.Jar == nil {
+		return nil, nil, errors.New("Configuration does not have a cookie jar")
+	}
+
+	schedulerURL, err := url.Parse(config.url)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Invalid scheduler URL")
+	}
+
+	return schedulerURL, config.httpClient.Jar, nil
+}
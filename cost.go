@@ -0,0 +1,137 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// Price of each resource for a period of time chosen by the caller, e.g. dollars per hour.
+type CostRates struct {
+	PerCPU    float64
+	PerRamGb  float64
+	PerDiskGb float64
+}
+
+// Cost of the resources requested by active tasks.
+type Cost struct {
+	CPU   float64
+	Ram   float64
+	Disk  float64
+	Total float64
+}
+
+func (c *Cost) add(other Cost) {
+	c.CPU += other.CPU
+	c.Ram += other.Ram
+	c.Disk += other.Disk
+	c.Total += other.Total
+}
+
+// Cost of a job as part of a role report.
+type JobCost struct {
+	JobKey *aurora.JobKey
+	Cost
+}
+
+// Cost of every job owned by a role, ordered by descending total.
+type RoleCost struct {
+	Role string
+	Jobs []JobCost
+	Cost
+}
+
+// Register the rates used by CostOfJob and CostOfRole.
+func (r *RealisClient) SetCostRates(rates CostRates) {
+	r.costRates = &rates
+}
+
+// Cost of the resources requested by the active tasks of a job.
+func (r *RealisClient) CostOfJob(key *aurora.JobKey) (*Cost, error) {
+	jobs, err := r.activeTaskCosts(&aurora.TaskQuery{
+		Role:        key.Role,
+		Environment: key.Environment,
+		JobName:     key.Name,
+		Statuses:    aurora.ACTIVE_STATES})
+	if err != nil {
+		return nil, err
+	}
+
+	cost := &Cost{}
+	for _, job := range jobs {
+		cost.add(job.Cost)
+	}
+	return cost, nil
+}
+
+// Cost of the resources requested by the active tasks of every job owned by a role.
+func (r *RealisClient) CostOfRole(role string) (*RoleCost, error) {
+	jobs, err := r.activeTaskCosts(&aurora.TaskQuery{Role: role, Statuses: aurora.ACTIVE_STATES})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RoleCost{Role: role}
+	for _, job := range jobs {
+		report.Jobs = append(report.Jobs, *job)
+		report.add(job.Cost)
+	}
+	sort.Slice(report.Jobs, func(i, j int) bool {
+		return report.Jobs[i].Total > report.Jobs[j].Total
+	})
+
+	return report, nil
+}
+
+func (r *RealisClient) activeTaskCosts(query *aurora.TaskQuery) (map[string]*JobCost, error) {
+	if r.costRates == nil {
+		return nil, errors.New("Cost rates have not been set")
+	}
+
+	tasks, err := r.GetTaskStatus(query)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to retrieve active tasks")
+	}
+
+	jobs := make(map[string]*JobCost)
+	for _, task := range tasks {
+		config := task.AssignedTask.Task
+		key := config.Job
+		id := key.Role + "/" + key.Environment + "/" + key.Name
+
+		job, ok := jobs[id]
+		if !ok {
+			job = &JobCost{JobKey: key}
+			jobs[id] = job
+		}
+		job.add(r.costRates.of(config))
+	}
+
+	return jobs, nil
+}
+
+func (rates *CostRates) of(config *aurora.TaskConfig) Cost {
+	cpus, ramMb, diskMb := taskResources(config)
+
+	cost := Cost{
+		CPU:  cpus * rates.PerCPU,
+		Ram:  float64(ramMb) / 1024 * rates.PerRamGb,
+		Disk: float64(diskMb) / 1024 * rates.PerDiskGb}
+	cost.Total = cost.CPU + cost.Ram + cost.Disk
+
+	return cost
+}
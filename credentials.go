@@ -0,0 +1,93 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+)
+
+// Basic authorization credentials.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Transports whose headers can be swapped for a single call.
+type headerEditor interface {
+	headerSetter
+	GetHeader(key string) string
+	DelHeader(key string)
+}
+
+// Use the given credentials for operations on jobs owned by role, letting a single client act
+// on behalf of many tenants. Operations on other roles and read-only queries keep using the
+// credentials set with AddBasicAuth.
+func (r *RealisClient) SetRoleCredentials(role string, credentials Credentials) error {
+	if _, ok := r.config.transport.(headerEditor); !ok {
+		return errors.New("Per role credentials require an HTTP transport")
+	}
+
+	r.settingsLock.Lock()
+	defer r.settingsLock.Unlock()
+
+	if r.roleCredentials == nil {
+		r.roleCredentials = make(map[string]Credentials)
+	}
+	r.roleCredentials[role] = credentials
+	return nil
+}
+
+// Invoke a mutating RPC on behalf of the role owning the operation's job. The call is queued
+// if the scheduler is unreachable and the mutation queue is enabled.
+func (r *RealisClient) thriftCallAs(op *Operation, name string, call rpcFunc) (*aurora.Response, error) {
+	response, err := r.callAs(op.JobKey.Role, name, call)
+	if err != nil && r.mutations != nil && schedulerUnavailable(err) {
+		return nil, r.queueMutation(op, name, call, err)
+	}
+	return response, err
+}
+
+// Invoke an RPC authenticating with the role's credentials if any were set.
+func (r *RealisClient) callAs(role string, name string, call rpcFunc) (*aurora.Response, error) {
+	r.settingsLock.RLock()
+	credentials, ok := r.roleCredentials[role]
+	r.settingsLock.RUnlock()
+	if !ok {
+		return r.thriftCall(name, call)
+	}
+
+	return r.withRetries(name, func() (*aurora.Response, error) {
+		return r.rpc(name, &credentials, call)
+	})
+}
+
+// Authenticate the connection's calls with the credentials, returning a function restoring
+// the previous ones. Connections are used by one call at a time so others never go out with
+// them.
+func (conn *connection) authenticate(credentials Credentials) func() {
+	editor := conn.transport.(headerEditor)
+	previous := editor.GetHeader("Authorization")
+
+	editor.DelHeader("Authorization")
+	editor.SetHeader("Authorization", "Basic "+basicAuth(credentials.Username, credentials.Password))
+
+	return func() {
+		editor.DelHeader("Authorization")
+		if previous != "" {
+			editor.SetHeader("Authorization", previous)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+// How DecommissionHosts retires hosts.
+type DecommissionOptions struct {
+	// Time allowed for the hosts to drain and their tasks to terminate. No limit when zero.
+	Timeout time.Duration
+
+	// Leave the hosts DRAINED, e.g. until they are shut down, instead of ending maintenance.
+	LeaveDrained bool
+}
+
+// When a host went through each step of its decommission. Steps not reached are zero.
+type HostTimeline struct {
+	Host             string
+	DrainRequested   time.Time
+	Drained          time.Time
+	TasksCleared     time.Time
+	MaintenanceEnded time.Time
+
+	// Maintenance mode of the host when the workflow ended.
+	Mode aurora.MaintenanceMode
+}
+
+// Retire hosts: drain them, wait until the scheduler reports them DRAINED and no active task
+// is left on them, then end maintenance unless the hosts are to be left drained. Returns the
+// timeline of every host, sorted by host, also when the workflow fails part way.
+func (r *RealisClient) DecommissionHosts(hosts []string, options DecommissionOptions) ([]HostTimeline, error) {
+	timelines := make(map[string]*HostTimeline)
+	for _, host := range hosts {
+		timelines[host] = &HostTimeline{Host: host}
+	}
+	report := func() []HostTimeline {
+		result := make([]HostTimeline, 0, len(timelines))
+		for _, timeline := range timelines {
+			result = append(result, *timeline)
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].Host < result[j].Host })
+		return result
+	}
+
+	fence := r.Fence()
+	response, err := r.DrainHosts(hosts...)
+	if err != nil {
+		return report(), err
+	}
+	if response.ResponseCode != aurora.ResponseCode_OK {
+		return report(), errors.Errorf("Drain rejected by Aurora Scheduler: %s", responseMessage(response))
+	}
+
+	start := r.clock.Now()
+	for _, timeline := range timelines {
+		timeline.DrainRequested = start
+	}
+
+	poll := r.newPoller()
+	for {
+		modes, err := r.MaintenanceStatus(hosts...)
+		if err != nil {
+			return report(), err
+		}
+
+		tasks, err := r.GetTasksWithoutConfigs(&aurora.TaskQuery{
+			SlaveHosts: hostSet(hosts).HostNames,
+			Statuses:   aurora.ACTIVE_STATES})
+		if err != nil {
+			return report(), errors.Wrap(err, "Unable to retrieve the tasks left on the hosts")
+		}
+
+		busy := make(map[string]int)
+		for _, task := range tasks {
+			busy[task.AssignedTask.SlaveHost]++
+		}
+
+		now := r.clock.Now()
+		pending := 0
+		for host, timeline := range timelines {
+			timeline.Mode = modes[host]
+			if timeline.Drained.IsZero() && timeline.Mode == aurora.MaintenanceMode_DRAINED {
+				timeline.Drained = now
+			}
+			if timeline.TasksCleared.IsZero() && busy[host] == 0 {
+				timeline.TasksCleared = now
+			}
+			if timeline.Drained.IsZero() || busy[host] > 0 {
+				pending++
+			}
+		}
+
+		if pending == 0 {
+			break
+		}
+
+		wait := poll.next(pending + len(tasks))
+		if options.Timeout > 0 && now.Add(wait).Sub(start) > options.Timeout {
+			return report(), errors.Errorf("Timed out after %v waiting for %d of %d hosts to drain, %d tasks left",
+				options.Timeout, pending, len(hosts), len(tasks))
+		}
+		r.clock.Sleep(wait)
+	}
+
+	if options.LeaveDrained {
+		return report(), nil
+	}
+
+	// The hosts were seen drained by the scheduler maintenance is ended on.
+	if err := fence.Check(); err != nil {
+		return report(), err
+	}
+
+	response, err = r.EndMaintenance(hosts...)
+	if err != nil {
+		return report(), err
+	}
+	if response.ResponseCode != aurora.ResponseCode_OK {
+		return report(), errors.Errorf("Ending maintenance rejected by Aurora Scheduler: %s", responseMessage(response))
+	}
+
+	ended := r.clock.Now()
+	for _, timeline := range timelines {
+		timeline.MaintenanceEnded = ended
+		timeline.Mode = aurora.MaintenanceMode_NONE
+	}
+
+	return report(), nil
+}
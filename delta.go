@@ -0,0 +1,96 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"gen-go/apache/aurora"
+	"sort"
+)
+
+// Task whose status changed between two observations.
+type TaskStatusChange struct {
+	Task *aurora.ScheduledTask
+	From aurora.ScheduleStatus
+	To   aurora.ScheduleStatus
+}
+
+// Difference between two observations of the same task query.
+type TaskDelta struct {
+	Added         []*aurora.ScheduledTask
+	Removed       []*aurora.ScheduledTask
+	StatusChanged []TaskStatusChange
+}
+
+func (d TaskDelta) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.StatusChanged) == 0
+}
+
+// Tasks added, removed or whose status changed from previous to current. Tasks are matched by
+// task id and listed in task id order.
+func DiffTasks(previous, current []*aurora.ScheduledTask) TaskDelta {
+	before := tasksById(previous)
+	after := tasksById(current)
+
+	var delta TaskDelta
+	for _, id := range sortedTaskIds(after) {
+		task := after[id]
+		old, ok := before[id]
+		if !ok {
+			delta.Added = append(delta.Added, task)
+		} else if old.Status != task.Status {
+			delta.StatusChanged = append(delta.StatusChanged, TaskStatusChange{Task: task, From: old.Status, To: task.Status})
+		}
+	}
+	for _, id := range sortedTaskIds(before) {
+		if _, ok := after[id]; !ok {
+			delta.Removed = append(delta.Removed, before[id])
+		}
+	}
+
+	return delta
+}
+
+// Digest of the ids and statuses of tasks, equal for results that differ only in order or in
+// details irrelevant to monitoring.
+func TaskFingerprint(tasks []*aurora.ScheduledTask) string {
+	byId := tasksById(tasks)
+
+	hash := sha1.New()
+	for _, id := range sortedTaskIds(byId) {
+		hash.Write([]byte(id + "=" + byId[id].Status.String() + "\n"))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func tasksById(tasks []*aurora.ScheduledTask) map[string]*aurora.ScheduledTask {
+	byId := make(map[string]*aurora.ScheduledTask, len(tasks))
+	for _, task := range tasks {
+		if task.AssignedTask != nil {
+			byId[task.AssignedTask.TaskId] = task
+		}
+	}
+	return byId
+}
+
+func sortedTaskIds(tasks map[string]*aurora.ScheduledTask) []string {
+	ids := make([]string, 0, len(tasks))
+	for id := range tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
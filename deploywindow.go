@@ -0,0 +1,82 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"time"
+)
+
+// Recurring period during which updates to jobs in the given environments are refused, e.g.
+// Friday from 17:00 to midnight for prod. Start and End are offsets from midnight; a
+// blackout with Start after End runs past midnight.
+type DeployBlackout struct {
+	// Environments the blackout applies to, every environment when empty.
+	Environments []string
+
+	// Days the blackout starts on, every day when empty.
+	Days []time.Weekday
+
+	Start time.Duration
+	End   time.Duration
+}
+
+func (b DeployBlackout) appliesTo(key *aurora.JobKey) bool {
+	if len(b.Environments) == 0 {
+		return true
+	}
+
+	for _, env := range b.Environments {
+		if env == key.Environment {
+			return true
+		}
+	}
+	return false
+}
+
+// Returned when an update is started during a deploy blackout without an override.
+type DeployWindowError struct {
+	JobKey   *aurora.JobKey
+	Blackout DeployBlackout
+	Time     time.Time
+}
+
+func (e *DeployWindowError) Error() string {
+	return fmt.Sprintf("updates to %s/%s/%s are not allowed at %s, override the deploy window to proceed",
+		e.JobKey.Role, e.JobKey.Environment, e.JobKey.Name, e.Time.Format("Mon 15:04 MST"))
+}
+
+// Policy rejecting StartJobUpdate during any of the blackouts, evaluated at the operation's
+// time in the given location (local time when nil). Updates built with OverrideDeployWindow are let through.
+func DeployWindowPolicy(location *time.Location, blackouts ...DeployBlackout) Policy {
+	if location == nil {
+		location = time.Local
+	}
+
+	return func(op *Operation) error {
+		if op.Name != "StartJobUpdate" || op.Override {
+			return nil
+		}
+
+		now := op.Time.In(location)
+		for _, blackout := range blackouts {
+			if blackout.appliesTo(op.JobKey) && inWindow(now, blackout.Days, blackout.Start, blackout.End) {
+				return &DeployWindowError{JobKey: op.JobKey, Blackout: blackout, Time: now}
+			}
+		}
+		return nil
+	}
+}
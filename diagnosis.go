@@ -0,0 +1,133 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"bytes"
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+// Diagnostic report describing why an update failed.
+type UpdateFailureReport struct {
+	Key    *aurora.JobUpdateKey
+	Status aurora.JobUpdateStatus
+
+	// Messages attached to the update's status transitions.
+	Messages []string
+
+	// Instances that failed to update or roll back, ordered by instance id.
+	Instances []InstanceFailure
+}
+
+// Task events recorded for an instance that failed during an update. Health check failures
+// and executor errors are surfaced by the scheduler as event messages.
+type InstanceFailure struct {
+	InstanceId int32
+	Events     []*aurora.TaskEvent
+}
+
+// Gather the update's messages and the task events of its failed instances into a single
+// report, meant to be attached to deploy logs after an update fails.
+func (r *RealisClient) ExplainUpdateFailure(key *aurora.JobUpdateKey) (*UpdateFailureReport, error) {
+	details, err := r.JobUpdateDetails(key)
+	if err != nil {
+		return nil, err
+	}
+
+	update := NewUpdateDetails(details)
+	report := &UpdateFailureReport{Key: key, Status: update.Summary().Status()}
+	created := update.Summary().CreatedAt()
+
+	for _, event := range update.Events() {
+		if event.Message != "" {
+			report.Messages = append(report.Messages, event.Message)
+		}
+	}
+
+	failed := make(map[int32]bool)
+	for _, event := range details.InstanceEvents {
+		if event.Action == aurora.JobUpdateAction_INSTANCE_UPDATE_FAILED ||
+			event.Action == aurora.JobUpdateAction_INSTANCE_ROLLBACK_FAILED {
+			failed[event.InstanceId] = true
+		}
+	}
+
+	if len(failed) == 0 {
+		return report, nil
+	}
+
+	tasks, err := r.GetTasksWithoutConfigs(&aurora.TaskQuery{
+		Role:        key.Job.Role,
+		Environment: key.Job.Environment,
+		JobName:     key.Job.Name,
+		InstanceIds: failed})
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to retrieve tasks of failed instances")
+	}
+
+	events := make(map[int32][]*aurora.TaskEvent)
+	for _, task := range tasks {
+		instanceId := task.AssignedTask.InstanceId
+		for _, event := range task.TaskEvents {
+			// Only events that happened during the update are relevant.
+			if !msToTime(event.Timestamp).Before(created) {
+				events[instanceId] = append(events[instanceId], event)
+			}
+		}
+	}
+
+	for instanceId := range failed {
+		instanceEvents := events[instanceId]
+		sort.Slice(instanceEvents, func(i, j int) bool {
+			return instanceEvents[i].Timestamp < instanceEvents[j].Timestamp
+		})
+		report.Instances = append(report.Instances, InstanceFailure{instanceId, instanceEvents})
+	}
+	sort.Slice(report.Instances, func(i, j int) bool {
+		return report.Instances[i].InstanceId < report.Instances[j].InstanceId
+	})
+
+	return report, nil
+}
+
+// Human readable rendering of the report.
+func (u *UpdateFailureReport) String() string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "Update %s of %s/%s/%s ended as %v\n",
+		u.Key.ID, u.Key.Job.Role, u.Key.Job.Environment, u.Key.Job.Name, u.Status)
+	for _, message := range u.Messages {
+		fmt.Fprintf(&buf, "  %s\n", message)
+	}
+
+	for _, instance := range u.Instances {
+		fmt.Fprintf(&buf, "Instance %d:\n", instance.InstanceId)
+		for _, event := range instance.Events {
+			message := ""
+			if event.Message != nil {
+				message = *event.Message
+			}
+			fmt.Fprintf(&buf, "  %s %-10v %s\n",
+				msToTime(event.Timestamp).UTC().Format(time.RFC3339),
+				event.Status, message)
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,49 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"github.com/pkg/errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How long to wait for a discovered scheduler to accept a connection before moving on.
+const discoveryDialTimeout = 2 * time.Second
+
+// Find a scheduler through the _aurora._tcp SRV records of a domain and return the URL of the
+// first endpoint, in priority and weight order, that accepts connections.
+func LookupSchedulerSRV(domain string) (string, error) {
+	_, records, err := net.LookupSRV("aurora", "tcp", domain)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error looking up SRV records for %s", domain)
+	}
+
+	for _, record := range records {
+		addr := net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+
+		conn, err := net.DialTimeout("tcp", addr, discoveryDialTimeout)
+		if err != nil {
+			continue
+		}
+		conn.Close()
+
+		return "http://" + addr, nil
+	}
+
+	return "", errors.Errorf("No reachable scheduler found among %d SRV record(s) for %s", len(records), domain)
+}
@@ -0,0 +1,61 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Describes how a task uses its disk allocation, so the request can be sized for jobs that
+// write large temporary files.
+type DiskSettings struct {
+	// Space used by the sandbox itself: executor logs, fetched URIs and unpacked artifacts.
+	SandboxMb int64
+
+	// Space for temporary files written by the task inside its sandbox.
+	ScratchMb int64
+}
+
+// Limits enforced by the cluster on the resources of a single task. Zero values are not checked.
+type ClusterLimits struct {
+	MaxDiskMb int64
+}
+
+// Request disk for the task based on how it is going to be used. The disk resource is set to
+// the sum of the sandbox and scratch space.
+func (a *Job) DiskSettings(settings DiskSettings) *Job {
+	a.disk = settings
+	return a.Disk(settings.SandboxMb + settings.ScratchMb)
+}
+
+// Check the job's disk request against the cluster limits and its own disk settings.
+func (a *Job) ValidateDisk(limits ClusterLimits) error {
+	disk := a.jobConfig.TaskConfig.DiskMb
+
+	if disk <= 0 {
+		return errors.New("Disk must be greater than 0")
+	}
+
+	if limits.MaxDiskMb > 0 && disk > limits.MaxDiskMb {
+		return errors.Errorf("Disk of %d MB exceeds the cluster limit of %d MB per task", disk, limits.MaxDiskMb)
+	}
+
+	if needed := a.disk.SandboxMb + a.disk.ScratchMb; needed > disk {
+		return errors.Errorf("Disk of %d MB can't hold %d MB of sandbox and %d MB of scratch space",
+			disk, a.disk.SandboxMb, a.disk.ScratchMb)
+	}
+
+	return nil
+}
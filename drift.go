@@ -0,0 +1,248 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"encoding/json"
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+)
+
+// Declared state of a job, read from a JSON file such as:
+//
+//	{"role": "vagrant", "environment": "prod", "name": "hello", "instances": 3,
+//	 "cpu": 0.5, "ram_mb": 256, "disk_mb": 512, "service": true,
+//	 "labels": {"team": "web"}}
+type JobSpec struct {
+	Role         string            `json:"role"`
+	Environment  string            `json:"environment"`
+	Name         string            `json:"name"`
+	Instances    int32             `json:"instances"`
+	CPU          float64           `json:"cpu"`
+	RamMb        int64             `json:"ram_mb"`
+	DiskMb       int64             `json:"disk_mb"`
+	Service      bool              `json:"service"`
+	MaxFailures  int32             `json:"max_failures"`
+	CronSchedule string            `json:"cron_schedule,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+
+	// Compared only when set.
+	ExecutorName string `json:"executor_name,omitempty"`
+	ExecutorData string `json:"executor_data,omitempty"`
+
+	// File the spec was read from.
+	Path string `json:"-"`
+}
+
+func (s *JobSpec) JobKey() *aurora.JobKey {
+	return &aurora.JobKey{Role: s.Role, Environment: s.Environment, Name: s.Name}
+}
+
+// Job built from the spec, ready to be created or updated.
+func (s *JobSpec) Job() *Job {
+	job := NewJob().
+		Role(s.Role).
+		Environment(s.Environment).
+		Name(s.Name).
+		InstanceCount(s.Instances).
+		CPU(s.CPU).
+		RAM(s.RamMb).
+		Disk(s.DiskMb).
+		IsService(s.Service).
+		MaxFailure(s.MaxFailures).
+		ExecutorName(s.ExecutorName).
+		ExecutorData(s.ExecutorData)
+
+	for key, value := range s.Labels {
+		job.AddLabel(key, value)
+	}
+	if s.CronSchedule != "" {
+		job.jobConfig.CronSchedule = &s.CronSchedule
+	}
+
+	return job
+}
+
+// Read every *.json file of a directory as a job spec.
+func LoadJobSpecs(dir string) ([]*JobSpec, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error listing job specs")
+	}
+	sort.Strings(paths)
+
+	specs := make([]*JobSpec, 0, len(paths))
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error reading job spec")
+		}
+
+		spec := &JobSpec{Path: path}
+		if err := json.Unmarshal(data, spec); err != nil {
+			return nil, errors.Wrapf(err, "Error parsing job spec %s", path)
+		}
+		if spec.Role == "" || spec.Environment == "" || spec.Name == "" {
+			return nil, errors.Errorf("Job spec %s is missing its role, environment or name", path)
+		}
+
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
+
+// Field whose live value differs from the declared one.
+type FieldDrift struct {
+	Field    string
+	Declared string
+	Live     string
+}
+
+// Job whose live configuration differs from its spec.
+type JobDrift struct {
+	Spec        *JobSpec
+	Differences []FieldDrift
+}
+
+// Result of comparing specs with the cluster.
+type DriftReport struct {
+	// Jobs whose live configuration differs from their spec.
+	Changed []JobDrift
+
+	// Specs without a matching job in the cluster.
+	Missing []*JobSpec
+
+	// Jobs without a spec, in the roles covered by the specs.
+	Unmanaged []*aurora.JobKey
+}
+
+func (d *DriftReport) InSync() bool {
+	return len(d.Changed) == 0 && len(d.Missing) == 0 && len(d.Unmanaged) == 0
+}
+
+// Compare specs with the jobs running in the cluster, e.g. for GitOps style audits. Only the
+// roles that appear in the specs are inspected for unmanaged jobs.
+func (r *RealisClient) Drift(specs []*JobSpec) (*DriftReport, error) {
+	byRole := make(map[string]map[string]*JobSpec)
+	for _, spec := range specs {
+		if byRole[spec.Role] == nil {
+			byRole[spec.Role] = make(map[string]*JobSpec)
+		}
+		byRole[spec.Role][spec.Environment+"/"+spec.Name] = spec
+	}
+
+	roles := make([]string, 0, len(byRole))
+	for role := range byRole {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	report := &DriftReport{}
+	for _, role := range roles {
+		configs, err := r.GetJobs(role)
+		if err != nil {
+			return nil, err
+		}
+
+		declared := byRole[role]
+		seen := make(map[string]bool)
+		for _, config := range configs {
+			id := config.Key.Environment + "/" + config.Key.Name
+			spec, ok := declared[id]
+			if !ok {
+				report.Unmanaged = append(report.Unmanaged, config.Key)
+				continue
+			}
+
+			seen[id] = true
+			if differences := specDrift(spec, config); len(differences) > 0 {
+				report.Changed = append(report.Changed, JobDrift{Spec: spec, Differences: differences})
+			}
+		}
+
+		for id, spec := range declared {
+			if !seen[id] {
+				report.Missing = append(report.Missing, spec)
+			}
+		}
+	}
+
+	sort.Slice(report.Missing, func(i, j int) bool {
+		return report.Missing[i].JobKey().String() < report.Missing[j].JobKey().String()
+	})
+	sort.Slice(report.Unmanaged, func(i, j int) bool {
+		return report.Unmanaged[i].String() < report.Unmanaged[j].String()
+	})
+
+	return report, nil
+}
+
+func specDrift(spec *JobSpec, config *aurora.JobConfiguration) []FieldDrift {
+	var differences []FieldDrift
+	compare := func(field string, declared, live interface{}) {
+		if d, l := fmt.Sprint(declared), fmt.Sprint(live); d != l {
+			differences = append(differences, FieldDrift{Field: field, Declared: d, Live: l})
+		}
+	}
+
+	task := config.TaskConfig
+	if task == nil {
+		task = aurora.NewTaskConfig()
+	}
+	cpus, ramMb, diskMb := taskResources(task)
+
+	compare("instances", spec.Instances, config.InstanceCount)
+	compare("cpu", spec.CPU, cpus)
+	compare("ram_mb", spec.RamMb, ramMb)
+	compare("disk_mb", spec.DiskMb, diskMb)
+	compare("service", spec.Service, task.IsService)
+	compare("max_failures", spec.MaxFailures, task.MaxTaskFailures)
+
+	cron := ""
+	if config.CronSchedule != nil {
+		cron = *config.CronSchedule
+	}
+	compare("cron_schedule", spec.CronSchedule, cron)
+
+	labels := make(map[string]string)
+	for metadata := range task.Metadata {
+		// Update settings are managed through SaveSettings rather than specs.
+		if metadata.Key != UpdateSettingsLabel {
+			labels[metadata.Key] = metadata.Value
+		}
+	}
+	declaredLabels := spec.Labels
+	if declaredLabels == nil {
+		declaredLabels = make(map[string]string)
+	}
+	// fmt prints maps sorted by key.
+	compare("labels", declaredLabels, labels)
+
+	if task.ExecutorConfig != nil {
+		if spec.ExecutorName != "" {
+			compare("executor_name", spec.ExecutorName, task.ExecutorConfig.Name)
+		}
+		if spec.ExecutorData != "" {
+			compare("executor_data", spec.ExecutorData, task.ExecutorConfig.Data)
+		}
+	}
+
+	return differences
+}
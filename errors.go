@@ -0,0 +1,197 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// Returned when the scheduler refuses an operation because the job is locked by an update
+// that is still in progress. Key identifies the conflicting update and is nil if it could
+// not be determined.
+type ErrUpdateInProgress struct {
+	Key     *aurora.JobUpdateKey
+	Message string
+}
+
+func (e *ErrUpdateInProgress) Error() string {
+	if e.Key != nil {
+		return fmt.Sprintf("Update %s already in progress for job %s/%s/%s: %s",
+			e.Key.ID, e.Key.Job.Role, e.Key.Job.Environment, e.Key.Job.Name, e.Message)
+	}
+	return "Update already in progress: " + e.Message
+}
+
+// Concatenate all detail messages attached to a response.
+func responseMessage(resp *aurora.Response) string {
+	if resp == nil {
+		return ""
+	}
+
+	messages := make([]string, 0, len(resp.Details))
+	for _, detail := range resp.Details {
+		messages = append(messages, detail.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Whether the scheduler rejected a request because an update holds the job's lock.
+func isUpdateInProgress(resp *aurora.Response) bool {
+	if resp == nil {
+		return false
+	}
+
+	switch resp.ResponseCode {
+	case aurora.ResponseCode_LOCK_ERROR:
+		return true
+	case aurora.ResponseCode_INVALID_REQUEST:
+		return strings.Contains(strings.ToLower(responseMessage(resp)), "active update")
+	default:
+		return false
+	}
+}
+
+// Build an ErrUpdateInProgress for a rejected response, looking up the conflicting update
+// when the scheduler did not include its key in the response.
+func (r *RealisClient) updateInProgressError(key *aurora.JobKey, resp *aurora.Response) error {
+	updateErr := &ErrUpdateInProgress{Message: responseMessage(resp)}
+
+	if resp.Result_ != nil && resp.Result_.StartJobUpdateResult_ != nil &&
+		resp.Result_.StartJobUpdateResult_.Key != nil {
+		updateErr.Key = resp.Result_.StartJobUpdateResult_.Key
+		return updateErr
+	}
+
+	if summaries, err := r.activeUpdateSummaries(key); err == nil && len(summaries) > 0 {
+		updateErr.Key = summaries[0].Key
+	}
+
+	return updateErr
+}
+
+// Walk the chain of errors wrapped by err, starting with err itself.
+func errorChain(err error) []error {
+	var chain []error
+	for err != nil {
+		chain = append(chain, err)
+
+		switch wrapped := err.(type) {
+		case interface{ Cause() error }:
+			err = wrapped.Cause()
+		case interface{ Unwrap() error }:
+			err = wrapped.Unwrap()
+		case interface{ Err() error }:
+			err = wrapped.Err()
+		default:
+			err = nil
+		}
+	}
+	return chain
+}
+
+// Non-OK response from the scheduler, carrying the detail messages it attached. Responses
+// with the codes below are reported as the more specific types embedding it.
+type ResponseError struct {
+	Code     aurora.ResponseCode
+	Messages []string
+	Response *aurora.Response
+}
+
+func (e *ResponseError) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("Aurora Scheduler answered %s", e.Code)
+	}
+	return fmt.Sprintf("Aurora Scheduler answered %s: %s", e.Code, strings.Join(e.Messages, "; "))
+}
+
+// Response with code WARNING, e.g. a request accepted with parts of it ignored.
+type WarningError struct{ ResponseError }
+
+// Response with code ERROR, a failure on the scheduler's side.
+type SchedulerError struct{ ResponseError }
+
+// Response with code INVALID_REQUEST, e.g. a bad job configuration.
+type InvalidRequestError struct{ ResponseError }
+
+// Response with code AUTH_FAILED, the credentials were rejected or lack permissions.
+type AuthFailedError struct{ ResponseError }
+
+// Convert a response that is not OK into an error of the type matching its code, nil for OK
+// responses.
+func CheckResponse(response *aurora.Response) error {
+	if response == nil {
+		return errors.New("No response from Aurora Scheduler")
+	}
+
+	if response.ResponseCode == aurora.ResponseCode_OK {
+		return nil
+	}
+
+	base := ResponseError{Code: response.ResponseCode, Response: response}
+	for _, detail := range response.Details {
+		base.Messages = append(base.Messages, detail.Message)
+	}
+
+	switch response.ResponseCode {
+	case aurora.ResponseCode_WARNING:
+		return &WarningError{base}
+	case aurora.ResponseCode_ERROR:
+		return &SchedulerError{base}
+	case aurora.ResponseCode_INVALID_REQUEST:
+		return &InvalidRequestError{base}
+	case aurora.ResponseCode_AUTH_FAILED:
+		return &AuthFailedError{base}
+	default:
+		return &base
+	}
+}
+
+// Whether err, or one it wraps, comes from an INVALID_REQUEST response.
+func IsInvalidRequest(err error) bool {
+	for _, e := range errorChain(err) {
+		if _, ok := e.(*InvalidRequestError); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether err, or one it wraps, comes from an AUTH_FAILED response.
+func IsAuthFailed(err error) bool {
+	for _, e := range errorChain(err) {
+		if _, ok := e.(*AuthFailedError); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Have every call answered with a response that is not OK fail with the error CheckResponse
+// makes of it, returned along with the response, instead of succeeding with a response the
+// caller must inspect. Responses refusing an operation because of an update in progress are
+// left to the methods reporting them as ErrUpdateInProgress. Off by default.
+func (r *RealisClient) SetCheckResponses(check bool) {
+	r.checkResponses = check
+}
+
+func (r *RealisClient) checkResponse(response *aurora.Response, err error) (*aurora.Response, error) {
+	if err != nil || !r.checkResponses || isUpdateInProgress(response) {
+		return response, err
+	}
+	return response, CheckResponse(response)
+}
@@ -0,0 +1,150 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// When and how KillWithEscalation forces tasks stuck in KILLING, e.g. on agents that stopped
+// responding, out of the scheduler.
+type KillEscalation struct {
+	// Time a task may stay KILLING before it is forced, 5 minutes when zero.
+	Threshold time.Duration
+
+	// State stuck tasks are forced to, KILLED when zero.
+	ForceTo aurora.ScheduleStatus
+
+	// Time allowed for every instance to terminate, forced or not. No limit when zero.
+	Timeout time.Duration
+}
+
+const defaultKillThreshold = 5 * time.Minute
+
+// Kill instances of a job, every active one when none are given, and wait for them to
+// terminate. Tasks lingering in KILLING for longer than the policy's threshold are forced into
+// a terminal state with the admin ForceTaskState call, publishing a KillEscalatedEvent each.
+// Returns the IDs of the forced tasks. Forcing requires administrator credentials and leaves
+// the tasks' sandboxes and processes on the agents as they are.
+func (r *RealisClient) KillWithEscalation(key *aurora.JobKey, policy KillEscalation, instances ...int32) ([]string, error) {
+	if err := ValidateJobKey(key); err != nil {
+		return nil, err
+	}
+
+	op := &Operation{Name: "KillWithEscalation", JobKey: key}
+	if err := r.authorize(op); err != nil {
+		return nil, err
+	}
+
+	if policy.Threshold <= 0 {
+		policy.Threshold = defaultKillThreshold
+	}
+	if policy.ForceTo == aurora.ScheduleStatus_PENDING {
+		policy.ForceTo = aurora.ScheduleStatus_KILLED
+	}
+	if !aurora.TERMINAL_STATES[policy.ForceTo] {
+		return nil, errors.Errorf("Tasks can only be forced to a terminal state, not %s", policy.ForceTo)
+	}
+
+	instanceIds := make(map[int32]bool)
+	for _, id := range instances {
+		instanceIds[id] = true
+	}
+	if len(instanceIds) == 0 {
+		var err error
+		if instanceIds, err = r.getActiveInstanceIds(key); err != nil {
+			return nil, errors.Wrap(err, "Could not retrieve relevant task instance IDs.")
+		}
+		if len(instanceIds) == 0 {
+			return nil, errors.New("No tasks in the Active state.")
+		}
+	}
+
+	response, err := r.thriftCallAs(op, "KillTasks", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.KillTasks(key, instanceIds)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error sending Kill command to Aurora Scheduler.")
+	}
+	if isUpdateInProgress(response) {
+		return nil, r.updateInProgressError(key, response)
+	}
+	if response.ResponseCode != aurora.ResponseCode_OK {
+		return nil, errors.Errorf("Kill rejected by Aurora Scheduler: %s", responseMessage(response))
+	}
+
+	start := r.clock.Now()
+	poll := r.newPoller()
+	var forced []string
+
+	for {
+		tasks, err := r.GetTasksWithoutConfigs(&aurora.TaskQuery{
+			Role:        key.Role,
+			Environment: key.Environment,
+			JobName:     key.Name,
+			InstanceIds: instanceIds,
+			Statuses:    aurora.ACTIVE_STATES})
+		if err != nil {
+			return forced, errors.Wrap(err, "Unable to retrieve killed tasks")
+		}
+
+		if len(tasks) == 0 {
+			return forced, nil
+		}
+
+		now := r.clock.Now()
+		for _, task := range tasks {
+			if task.Status != aurora.ScheduleStatus_KILLING || now.Sub(msToTime(lastEventMs(task))) < policy.Threshold {
+				continue
+			}
+
+			taskId := task.AssignedTask.TaskId
+			if err := r.forceTaskState(taskId, policy.ForceTo); err != nil {
+				return forced, err
+			}
+
+			forced = append(forced, taskId)
+			r.emit(Event{
+				Type:    KillEscalatedEvent,
+				JobKey:  key,
+				Message: fmt.Sprintf("Forced task %s KILLING for over %v to %s", taskId, policy.Threshold, policy.ForceTo),
+				Payload: taskId})
+		}
+
+		wait := poll.next(len(tasks))
+		if policy.Timeout > 0 && now.Add(wait).Sub(start) > policy.Timeout {
+			return forced, errors.Errorf("Timed out after %v waiting for %d tasks of %s/%s/%s to terminate",
+				policy.Timeout, len(tasks), key.Role, key.Environment, key.Name)
+		}
+		r.clock.Sleep(wait)
+	}
+}
+
+func (r *RealisClient) forceTaskState(taskId string, status aurora.ScheduleStatus) error {
+	response, err := r.thriftCall("ForceTaskState", func(conn *connection) (*aurora.Response, error) {
+		return conn.adminClient.ForceTaskState(taskId, status)
+	})
+	if err != nil {
+		return errors.Wrap(err, "Error sending ForceTaskState command to Aurora Scheduler")
+	}
+
+	if response.ResponseCode != aurora.ResponseCode_OK {
+		return errors.Errorf("Unable to force task %s to %s: %s", taskId, status, responseMessage(response))
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"time"
+)
+
+// Layout of an event once serialized by FormatEventJSON.
+type jsonEvent struct {
+	Time         string      `json:"time"`
+	Type         EventType   `json:"type"`
+	Message      string      `json:"message,omitempty"`
+	Job          string      `json:"job,omitempty"`
+	RPC          string      `json:"rpc,omitempty"`
+	DurationMs   float64     `json:"duration_ms,omitempty"`
+	ResponseCode string      `json:"response_code,omitempty"`
+	Error        string      `json:"error,omitempty"`
+	Payload      interface{} `json:"payload,omitempty"`
+}
+
+// Serialize an event as a single line JSON document with stable field names, so logs of tools
+// built on the client can be ingested by log pipelines without custom parsing.
+func FormatEventJSON(event Event) ([]byte, error) {
+	record := jsonEvent{
+		Time:    event.Time.UTC().Format(time.RFC3339Nano),
+		Type:    event.Type,
+		Message: event.Message}
+
+	if event.JobKey != nil {
+		record.Job = event.JobKey.Role + "/" + event.JobKey.Environment + "/" + event.JobKey.Name
+	}
+
+	switch payload := event.Payload.(type) {
+	case RPCInfo:
+		record.RPC = payload.Name
+		if event.Type == RPCEndEvent {
+			record.DurationMs = float64(payload.Duration) / float64(time.Millisecond)
+			if payload.Err != nil {
+				record.Error = payload.Err.Error()
+			} else {
+				record.ResponseCode = payload.ResponseCode.String()
+			}
+		}
+	case error:
+		record.Error = payload.Error()
+	default:
+		record.Payload = payload
+	}
+
+	return json.Marshal(record)
+}
+
+// Write every event received on the channel to w as a line of JSON. Returns when the channel
+// is closed or writing fails.
+func LogEventsJSON(events <-chan Event, w io.Writer) error {
+	for event := range events {
+		line, err := FormatEventJSON(event)
+		if err != nil {
+			return errors.Wrap(err, "Error serializing event")
+		}
+
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return errors.Wrap(err, "Error writing event")
+		}
+	}
+	return nil
+}
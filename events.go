@@ -0,0 +1,185 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+// Number of events buffered by default before the overflow policy kicks in.
+const eventBufferSize = 100
+
+// What an event stream does when its buffer is full because the consumer is too slow.
+type OverflowPolicy int
+
+const (
+	// Discard the event being published.
+	DropNewest OverflowPolicy = iota
+
+	// Discard the oldest buffered event to make room.
+	DropOldest
+
+	// Wait for the consumer, slowing down the publisher.
+	Block
+
+	// Close the stream; the reason is then reported by the stream's Err method.
+	FailOnOverflow
+)
+
+// Reported by event streams closed because of the FailOnOverflow policy.
+var ErrEventOverflow = errors.New("Event consumer too slow, buffer overflowed")
+
+type EventType string
+
+const (
+	SchedulerAlertEvent      EventType = "SCHEDULER_ALERT"
+	SchedulerStatsErrorEvent EventType = "SCHEDULER_STATS_ERROR"
+	RPCStartEvent            EventType = "RPC_START"
+	RPCEndEvent              EventType = "RPC_END"
+	AutoscaleEvent           EventType = "AUTOSCALE"
+	AutoscaleErrorEvent      EventType = "AUTOSCALE_ERROR"
+	StuckUpdateEvent         EventType = "STUCK_UPDATE"
+	WatchdogErrorEvent       EventType = "WATCHDOG_ERROR"
+	LeaderFailoverEvent      EventType = "LEADER_FAILOVER"
+	RPCRetryEvent            EventType = "RPC_RETRY"
+	KillEscalatedEvent       EventType = "KILL_ESCALATED"
+)
+
+// Notification sent by the client through its event channel. Payload holds a value
+// specific to the type of the event, e.g. a SchedulerAlert for SchedulerAlertEvent.
+type Event struct {
+	Type    EventType
+	Time    time.Time
+	JobKey  *aurora.JobKey
+	Message string
+	Payload interface{}
+}
+
+// Bounded event channel applying an overflow policy, used for the client's events and the
+// events of monitor sets.
+type eventStream[T any] struct {
+	mu      sync.Mutex
+	ch      chan T
+	policy  OverflowPolicy
+	dropped uint64
+	err     error
+}
+
+func newEventStream[T any](size int, policy OverflowPolicy) *eventStream[T] {
+	return &eventStream[T]{ch: make(chan T, size), policy: policy}
+}
+
+// Returns whether the event was sent.
+func (s *eventStream[T]) publish(event T) bool {
+	// Blocking sends happen outside the lock so the consumer can still query the stream. The
+	// channel is never closed under this policy.
+	if s.policy == Block {
+		s.ch <- event
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return false
+	}
+
+	select {
+	case s.ch <- event:
+		return true
+	default:
+	}
+
+	switch s.policy {
+	case DropNewest:
+		s.dropped++
+	case DropOldest:
+		for {
+			select {
+			case <-s.ch:
+				s.dropped++
+			default:
+			}
+
+			select {
+			case s.ch <- event:
+				return true
+			default:
+			}
+		}
+	case FailOnOverflow:
+		s.err = ErrEventOverflow
+		close(s.ch)
+	}
+	return false
+}
+
+func (s *eventStream[T]) droppedEvents() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+func (s *eventStream[T]) closeErr() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Channel on which the client publishes events. By default events are dropped if nobody is
+// consuming them and the buffer is full, see SetEventBuffer.
+func (r *RealisClient) Events() <-chan Event {
+	return r.events.ch
+}
+
+// Replace the client's event channel with one buffering size events and applying the given
+// policy when full. Must be called before Events and before the client is shared between
+// goroutines.
+func (r *RealisClient) SetEventBuffer(size int, policy OverflowPolicy) {
+	r.events = newEventStream[Event](size, policy)
+}
+
+// Number of events discarded because the buffer was full.
+func (r *RealisClient) DroppedEvents() uint64 {
+	return r.events.droppedEvents()
+}
+
+// Why the event channel was closed, nil while it is open.
+func (r *RealisClient) EventsErr() error {
+	return r.events.closeErr()
+}
+
+// Publish an event according to the event buffer's overflow policy. Never blocks the caller
+// unless the Block policy was chosen.
+func (r *RealisClient) emit(event Event) {
+	if event.Time.IsZero() {
+		event.Time = r.clock.Now()
+	}
+
+	r.logEvent(event)
+	r.events.publish(event)
+}
+
+// Publish an event on the client's event channel. Implementations of Realis other than
+// RealisClient have none and the event is dropped.
+func emitTo(client Realis, event Event) {
+	if r, ok := client.(*RealisClient); ok {
+		r.emit(event)
+	}
+}
@@ -0,0 +1,76 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Keeps the hello world job between a minimum and maximum number of instances, scaling it on
+// the number of pending tasks in the cluster, until interrupted.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/rdelval/gorealis"
+	"github.com/rdelval/gorealis/examples/common"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func main() {
+	url := flag.String("url", "", "URL at which the Aurora Scheduler exists as [url]:[port]")
+	username := flag.String("username", "aurora", "Username to use for authorization")
+	password := flag.String("password", "secret", "Password to use for authorization")
+	min := flag.Int("min", 1, "Minimum number of instances")
+	max := flag.Int("max", 5, "Maximum number of instances")
+	flag.Parse()
+
+	r := common.Connect(*url, *username, *password)
+	defer r.Close()
+
+	key := &aurora.JobKey{Role: "vagrant", Environment: "prod", Name: "hello_world_from_gorealis"}
+
+	// Add an instance while the job has pending tasks, remove one when all are running.
+	metric := func(key *aurora.JobKey, current int32) (int32, error) {
+		tasks, err := r.GetTasksWithoutConfigs(&aurora.TaskQuery{
+			Role:        key.Role,
+			Environment: key.Environment,
+			JobName:     key.Name,
+			Statuses:    map[aurora.ScheduleStatus]bool{aurora.ScheduleStatus_PENDING: true}})
+		if err != nil {
+			return current, err
+		}
+		if len(tasks) > 0 {
+			return current + 1, nil
+		}
+		return current - 1, nil
+	}
+
+	scaler := realis.NewAutoscaler(r, key, metric, realis.AutoscalerConfig{
+		Min:               int32(*min),
+		Max:               int32(*max),
+		Interval:          30 * time.Second,
+		ScaleUpCooldown:   time.Minute,
+		ScaleDownCooldown: 5 * time.Minute})
+
+	stop := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		close(stop)
+	}()
+
+	fmt.Println("Autoscaling, interrupt to stop")
+	scaler.Run(stop)
+}
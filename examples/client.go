@@ -33,18 +33,21 @@ func main() {
 	password := flag.String("password", "secret", "Password to use for authorization")
 	flag.Parse()
 
-	//Create new configuration with default transport layer
-	config, err := realis.NewDefaultConfig(*url)
+	// Configured for vagrant
+	r, err := realis.NewRealisClient(
+		realis.WithURL(*url),
+		realis.WithBasicAuth(*username, *password))
 	if err != nil {
-		fmt.Print(err)
+		fmt.Println(err)
 		os.Exit(1)
 	}
-
-	// Configured for vagrant
-	realis.AddBasicAuth(&config, *username, *password)
-	r := realis.NewClient(config)
 	defer r.Close()
 
+	if err := r.VerifyConnection(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	var job *realis.Job
 
 	switch *executor {
@@ -146,8 +149,21 @@ func main() {
 		}
 		fmt.Print(response.String())
 		break
+	case "taskStatus":
+		fmt.Println("Getting task status")
+		key := job.JobKey()
+		tasks, err := r.GetTaskStatus(&aurora.TaskQuery{Role: key.Role, Environment: key.Environment, JobName: key.Name})
+		if err != nil {
+			fmt.Print(err)
+			os.Exit(1)
+		}
+
+		if err := realis.WriteTasks(os.Stdout, realis.NewTasks(tasks), realis.TableFormat); err != nil {
+			fmt.Print(err)
+		}
+		break
 	default:
-		fmt.Println("Only create, kill, restart, flexUp, update, and abortUpdate are supported now")
+		fmt.Println("Only create, kill, restart, flexUp, update, abortUpdate, and taskStatus are supported now")
 		os.Exit(1)
 	}
 }
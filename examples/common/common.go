@@ -0,0 +1,88 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Helpers shared by the example programs.
+package common
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/rdelval/gorealis"
+	"io/ioutil"
+	"os"
+)
+
+// Hello world job running the Thermos payload.
+func HelloJob(payload string) *realis.Job {
+	return realis.NewJob().
+		Environment("prod").
+		Role("vagrant").
+		Name("hello_world_from_gorealis").
+		ExecutorName(aurora.AURORA_EXECUTOR_NAME).
+		ExecutorData(payload).
+		CPU(1).
+		RAM(64).
+		Disk(100).
+		IsService(true).
+		InstanceCount(1).
+		AddPorts(1)
+}
+
+func ReadPayload(path string) string {
+	payload, err := ioutil.ReadFile(path)
+	if err != nil {
+		Fail(err)
+	}
+	return string(payload)
+}
+
+// Connect to the scheduler and print every RPC made, with its duration, so runs against a
+// test cluster double as smoke tests.
+func Connect(url, username, password string) *realis.RealisClient {
+	config, err := realis.NewDefaultConfig(url)
+	if err != nil {
+		Fail(err)
+	}
+
+	if err := realis.AddBasicAuth(&config, username, password); err != nil {
+		Fail(err)
+	}
+
+	r, err := realis.NewClient(config)
+	if err != nil {
+		Fail(err)
+	}
+
+	if err := r.VerifyConnection(); err != nil {
+		Fail(err)
+	}
+
+	go func() {
+		for event := range r.Events() {
+			if info, ok := event.Payload.(realis.RPCInfo); ok && event.Type == realis.RPCEndEvent {
+				fmt.Printf("rpc %s took %v (%v)\n", info.Name, info.Duration, info.ResponseCode)
+			} else if event.Type != realis.RPCStartEvent {
+				fmt.Printf("event %s: %s\n", event.Type, event.Message)
+			}
+		}
+	}()
+
+	return r
+}
+
+// Print the error and exit.
+func Fail(err error) {
+	fmt.Println(err)
+	os.Exit(1)
+}
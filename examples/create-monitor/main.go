@@ -0,0 +1,79 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Creates the hello world job and follows its tasks until the requested number of instances
+// is running and stable.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/rdelval/gorealis"
+	"github.com/rdelval/gorealis/examples/common"
+	"time"
+)
+
+func main() {
+	url := flag.String("url", "", "URL at which the Aurora Scheduler exists as [url]:[port]")
+	username := flag.String("username", "aurora", "Username to use for authorization")
+	password := flag.String("password", "secret", "Password to use for authorization")
+	payload := flag.String("payload", "examples/thermos_payload.json", "Thermos payload of the job")
+	timeout := flag.Duration("timeout", 5*time.Minute, "How long to wait for the job to run")
+	flag.Parse()
+
+	r := common.Connect(*url, *username, *password)
+	defer r.Close()
+
+	job := common.HelloJob(common.ReadPayload(*payload))
+	if _, err := r.CreateJob(job); err != nil {
+		common.Fail(err)
+	}
+
+	monitors := realis.NewMonitorSet(r, 5*time.Second, 0)
+	tasksId := monitors.WatchTasks(&aurora.TaskQuery{
+		Role:        job.JobKey().Role,
+		Environment: job.JobKey().Environment,
+		JobName:     job.JobKey().Name,
+		Statuses:    aurora.ACTIVE_STATES})
+	monitors.WatchInstances(job.JobKey(), 1)
+
+	stop := make(chan struct{})
+	go monitors.Run(stop)
+
+	deadline := time.After(*timeout)
+	for monitors.Len() > 1 {
+		select {
+		case event := <-monitors.Events():
+			if event.Err != nil {
+				fmt.Println("monitor error:", event.Err)
+			} else if event.ID == tasksId && event.Delta != nil {
+				fmt.Printf("tasks: %d added, %d removed, %d changed status\n",
+					len(event.Delta.Added), len(event.Delta.Removed), len(event.Delta.StatusChanged))
+			} else {
+				fmt.Printf("%d instance(s) running\n", event.RunningInstances)
+			}
+		case <-deadline:
+			close(stop)
+			common.Fail(fmt.Errorf("Timed out after %v waiting for the job to run", *timeout))
+		}
+	}
+	monitors.Remove(tasksId)
+	close(stop)
+
+	if err := r.AwaitStable(job.JobKey(), 30*time.Second, *timeout); err != nil {
+		common.Fail(err)
+	}
+	fmt.Println("Job is running and stable")
+}
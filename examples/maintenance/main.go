@@ -0,0 +1,84 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Reports the maintenance status of hosts, checks whether the hello world job can still be
+// updated safely, and follows the hosts until they are drained.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/rdelval/gorealis"
+	"github.com/rdelval/gorealis/examples/common"
+	"strings"
+	"time"
+)
+
+func main() {
+	url := flag.String("url", "", "URL at which the Aurora Scheduler exists as [url]:[port]")
+	username := flag.String("username", "aurora", "Username to use for authorization")
+	password := flag.String("password", "secret", "Password to use for authorization")
+	hosts := flag.String("hosts", "", "Comma separated hosts under maintenance")
+	timeout := flag.Duration("timeout", 10*time.Minute, "How long to wait for the hosts to drain")
+	flag.Parse()
+
+	if *hosts == "" {
+		common.Fail(fmt.Errorf("No hosts given"))
+	}
+	hostNames := strings.Split(*hosts, ",")
+
+	r := common.Connect(*url, *username, *password)
+	defer r.Close()
+
+	modes, err := r.MaintenanceStatus(hostNames...)
+	if err != nil {
+		common.Fail(err)
+	}
+	for host, mode := range modes {
+		fmt.Printf("%s is %v\n", host, mode)
+	}
+
+	key := &aurora.JobKey{Role: "vagrant", Environment: "prod", Name: "hello_world_from_gorealis"}
+	if err := r.CheckMaintenanceGate(key, 0.25); err != nil {
+		fmt.Println("Updates of the job would be refused:", err)
+	} else {
+		fmt.Println("The job can still be updated")
+	}
+
+	monitors := realis.NewMonitorSet(r, 5*time.Second, 0)
+	monitors.WatchHosts(aurora.MaintenanceMode_DRAINED, hostNames...)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go monitors.Run(stop)
+
+	deadline := time.After(*timeout)
+	for {
+		select {
+		case event := <-monitors.Events():
+			if event.Err != nil {
+				fmt.Println("monitor error:", event.Err)
+				continue
+			}
+			fmt.Printf("modes: %v\n", event.HostModes)
+			if event.Done {
+				fmt.Println("All hosts drained")
+				return
+			}
+		case <-deadline:
+			common.Fail(fmt.Errorf("Timed out after %v waiting for the hosts to drain", *timeout))
+		}
+	}
+}
@@ -0,0 +1,64 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Rolls the hello world job out to a new instance count with the safe update preset, waits
+// for the update to finish and explains why it failed if it did.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/rdelval/gorealis"
+	"github.com/rdelval/gorealis/examples/common"
+	"os"
+	"time"
+)
+
+func main() {
+	url := flag.String("url", "", "URL at which the Aurora Scheduler exists as [url]:[port]")
+	username := flag.String("username", "aurora", "Username to use for authorization")
+	password := flag.String("password", "secret", "Password to use for authorization")
+	payload := flag.String("payload", "examples/thermos_payload.json", "Thermos payload of the job")
+	instances := flag.Int("instances", 3, "Instance count after the update")
+	timeout := flag.Duration("timeout", 10*time.Minute, "How long to wait for the update")
+	flag.Parse()
+
+	r := common.Connect(*url, *username, *password)
+	defer r.Close()
+
+	job := common.HelloJob(common.ReadPayload(*payload))
+	update := realis.NewUpdateJob(job).
+		Preset(realis.SafeUpdate).
+		InstanceCount(int32(*instances))
+
+	if err := r.AwaitUpdateSlot(job.JobKey(), *timeout); err != nil {
+		common.Fail(err)
+	}
+
+	result := <-r.StartJobUpdateAsync(update, "Rolling update from the gorealis examples", *timeout)
+	if result.Err != nil {
+		common.Fail(result.Err)
+	}
+
+	fmt.Printf("Update %s finished as %v\n", result.UpdateKey.ID, result.Status)
+	if result.Status != aurora.JobUpdateStatus_ROLLED_FORWARD {
+		report, err := r.ExplainUpdateFailure(result.UpdateKey)
+		if err != nil {
+			common.Fail(err)
+		}
+		fmt.Printf("%+v\n", report)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,218 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// How long an endpoint that failed is skipped before being tried again.
+const endpointCooldown = 30 * time.Second
+
+// Health of a scheduler endpoint as observed by the client.
+type EndpointStatus struct {
+	URL         string
+	Healthy     bool
+	Failures    int
+	LastFailure time.Time
+	LastError   string
+}
+
+// Create a configuration that spreads calls over a list of schedulers, moving to the next one
+// when a scheduler can't be reached or answers as a non-leader. Useful for clusters that do
+// not expose ZooKeeper to clients.
+func NewConfigWithEndpoints(urls []string) (RealisConfig, error) {
+	return newFailoverConfig(urls, defaultAPIPath)
+}
+
+// Create a failover configuration for schedulers serving their API somewhere other than /api,
+// see NewConfigWithEndpoints and NewDefaultConfigWithAPIPath.
+func NewConfigWithEndpointsAndAPIPath(urls []string, apiPath string) (RealisConfig, error) {
+	apiPath, err := normalizeAPIPath(apiPath)
+	if err != nil {
+		return RealisConfig{}, err
+	}
+
+	return newFailoverConfig(urls, apiPath)
+}
+
+func newFailoverConfig(urls []string, apiPath string) (RealisConfig, error) {
+	if len(urls) == 0 {
+		return RealisConfig{}, errors.New("At least one scheduler URL is required")
+	}
+
+	failover := &failoverTransport{next: http.DefaultTransport.(*http.Transport).Clone()}
+	for _, addr := range urls {
+		base, err := normalizeURL(addr, apiPath)
+		if err != nil {
+			return RealisConfig{}, err
+		}
+
+		apiURL, err := url.Parse(base + apiPath)
+		if err != nil {
+			return RealisConfig{}, errors.Wrapf(err, "Malformed scheduler URL %q", base)
+		}
+
+		failover.endpoints = append(failover.endpoints, &endpoint{base: base, apiURL: apiURL})
+	}
+
+	config, err := newHTTPConfig(failover.endpoints[0].base, apiPath, failover)
+	if err != nil {
+		return RealisConfig{}, err
+	}
+
+	config.failover = failover
+	return config, nil
+}
+
+// Health of every endpoint configured through NewConfigWithEndpoints.
+func (r *RealisClient) Endpoints() []EndpointStatus {
+	if r.config.failover == nil {
+		return nil
+	}
+	return r.config.failover.status()
+}
+
+type endpoint struct {
+	base        string
+	apiURL      *url.URL
+	failures    int
+	lastFailure time.Time
+	lastError   string
+}
+
+func (e *endpoint) healthy(now time.Time) bool {
+	return e.failures == 0 || now.Sub(e.lastFailure) > endpointCooldown
+}
+
+type failoverTransport struct {
+	next      http.RoundTripper
+	lock      sync.Mutex
+	endpoints []*endpoint
+	current   int
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var lastErr error
+
+	for _, ep := range t.candidates() {
+		attempt := new(http.Request)
+		*attempt = *req
+		apiURL := *ep.apiURL
+		attempt.URL = &apiURL
+		attempt.Host = ""
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, errors.Wrap(err, "Error rewinding request body")
+			}
+			attempt.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(attempt)
+		if err == nil && !nonLeaderResponse(resp) {
+			t.succeeded(ep)
+			return resp, nil
+		}
+
+		if err == nil {
+			resp.Body.Close()
+			err = errors.Errorf("Scheduler %s answered %s", ep.base, resp.Status)
+		}
+
+		t.failed(ep, err)
+		lastErr = err
+
+		if req.Body != nil && req.GetBody == nil {
+			break
+		}
+	}
+
+	return nil, errors.Wrap(lastErr, "No scheduler endpoint could serve the request")
+}
+
+// Endpoints in the order they should be tried: the current one first, then the remaining
+// healthy ones, then the ones still cooling down after a failure.
+func (t *failoverTransport) candidates() []*endpoint {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	var healthy, unhealthy []*endpoint
+	for i := range t.endpoints {
+		ep := t.endpoints[(t.current+i)%len(t.endpoints)]
+		if ep.healthy(now) {
+			healthy = append(healthy, ep)
+		} else {
+			unhealthy = append(unhealthy, ep)
+		}
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+func (t *failoverTransport) succeeded(ep *endpoint) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	ep.failures = 0
+	for i, candidate := range t.endpoints {
+		if candidate == ep {
+			t.current = i
+		}
+	}
+}
+
+func (t *failoverTransport) failed(ep *endpoint, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	ep.failures++
+	ep.lastFailure = time.Now()
+	ep.lastError = err.Error()
+}
+
+func (t *failoverTransport) status() []EndpointStatus {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	statuses := make([]EndpointStatus, 0, len(t.endpoints))
+	for _, ep := range t.endpoints {
+		statuses = append(statuses, EndpointStatus{
+			URL:         ep.base,
+			Healthy:     ep.healthy(now),
+			Failures:    ep.failures,
+			LastFailure: ep.lastFailure,
+			LastError:   ep.lastError})
+	}
+	return statuses
+}
+
+// Schedulers that are not the leader, or have no leader to redirect to, answer with one of
+// these codes.
+func nonLeaderResponse(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
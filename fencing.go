@@ -0,0 +1,144 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Returned by Fence.Check when a different scheduler answered since the fence was set, e.g.
+// because the leader changed in the middle of a multi-step operation.
+type LeaderChangedError struct {
+	Previous string
+	Current  string
+
+	// Number of leader changes observed since the fence was set.
+	Changes uint64
+}
+
+func (e *LeaderChangedError) Error() string {
+	return fmt.Sprintf("Scheduler leader changed from %s to %s (%d change(s)), state read before may be stale",
+		e.Previous, e.Current, e.Changes)
+}
+
+// Whether err was caused by a leader change.
+func IsLeaderChanged(err error) bool {
+	for _, e := range errorChain(err) {
+		if _, ok := e.(*LeaderChangedError); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Marks the leader at the start of a multi-step operation. Checking the fence between steps
+// tells whether later steps talk to the leader earlier steps read state from.
+type Fence struct {
+	tracker    *leaderTracker
+	leader     string
+	generation uint64
+}
+
+// Address of the scheduler that answered the last successful call, empty before the first one
+// or for non-HTTP transports.
+func (r *RealisClient) Leader() string {
+	if r.leaders == nil {
+		return ""
+	}
+	leader, _ := r.leaders.current()
+	return leader
+}
+
+// Set a fence at the current leader. Fences never fail for non-HTTP transports.
+func (r *RealisClient) Fence() *Fence {
+	fence := &Fence{tracker: r.leaders}
+	if r.leaders != nil {
+		fence.leader, fence.generation = r.leaders.current()
+	}
+	return fence
+}
+
+// Return a *LeaderChangedError if a scheduler other than the one seen when the fence was set
+// answered since, even if the original leader is back. A fence set before the first call is
+// bound to the first leader seen.
+func (f *Fence) Check() error {
+	if f.tracker == nil {
+		return nil
+	}
+
+	leader, generation := f.tracker.current()
+	if generation == f.generation {
+		return nil
+	}
+	previous := f.leader
+	if previous == "" {
+		previous = f.tracker.firstLeader()
+	}
+	return &LeaderChangedError{Previous: previous, Current: leader, Changes: generation - f.generation}
+}
+
+// Records which scheduler answers API calls. Non-leading schedulers redirect to the leader,
+// so the host of the last successful response is the leader.
+type leaderTracker struct {
+	next http.RoundTripper
+
+	lock       sync.Mutex
+	leader     string
+	generation uint64
+	first      string
+}
+
+func (t *leaderTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusOK {
+		// Transports below may have sent the request elsewhere, e.g. when failing over.
+		served := req
+		if resp.Request != nil {
+			served = resp.Request
+		}
+		t.observe(served.URL.Host)
+	}
+	return resp, err
+}
+
+func (t *leaderTracker) observe(host string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if host == t.leader {
+		return
+	}
+	// The first leader seen is generation 0, so fences set before any call bind to it.
+	if t.leader == "" {
+		t.first = host
+	} else {
+		t.generation++
+	}
+	t.leader = host
+}
+
+func (t *leaderTracker) current() (string, uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.leader, t.generation
+}
+
+func (t *leaderTracker) firstLeader() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.first
+}
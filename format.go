@@ -0,0 +1,150 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"encoding/csv"
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type OutputFormat string
+
+const (
+	TableFormat OutputFormat = "table"
+	CSVFormat   OutputFormat = "csv"
+)
+
+// Render tasks with one row per task, ordered by instance id.
+func WriteTasks(w io.Writer, tasks []*Task, format OutputFormat) error {
+	sorted := append([]*Task(nil), tasks...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].InstanceID() < sorted[j].InstanceID()
+	})
+
+	rows := make([][]string, 0, len(sorted))
+	for _, task := range sorted {
+		ports := make([]string, 0)
+		for name, port := range task.Ports() {
+			ports = append(ports, name+"="+strconv.Itoa(int(port)))
+		}
+		sort.Strings(ports)
+
+		launched := ""
+		if launchedAt := task.LaunchedAt(); !launchedAt.IsZero() {
+			launched = launchedAt.UTC().Format("2006-01-02T15:04:05Z")
+		}
+
+		rows = append(rows, []string{
+			strconv.Itoa(int(task.InstanceID())),
+			task.Status().String(),
+			task.Host(),
+			strings.Join(ports, ","),
+			launched,
+			task.ID()})
+	}
+
+	return writeRows(w, format, []string{"INSTANCE", "STATUS", "HOST", "PORTS", "LAUNCHED", "TASK ID"}, rows)
+}
+
+// Render job summaries with one row per job, ordered by job key. Summaries without a job key
+// are skipped.
+func WriteJobSummaries(w io.Writer, summaries []*aurora.JobSummary, format OutputFormat) error {
+	rows := make([][]string, 0, len(summaries))
+	for _, summary := range summaries {
+		if summary == nil || summary.Job == nil || summary.Job.Key == nil {
+			continue
+		}
+
+		key := summary.Job.Key
+		stats := summary.Stats
+		if stats == nil {
+			stats = &aurora.JobStats{}
+		}
+
+		rows = append(rows, []string{
+			key.Role + "/" + key.Environment + "/" + key.Name,
+			strconv.Itoa(int(summary.Job.InstanceCount)),
+			strconv.Itoa(int(stats.ActiveTaskCount)),
+			strconv.Itoa(int(stats.PendingTaskCount)),
+			strconv.Itoa(int(stats.FinishedTaskCount)),
+			strconv.Itoa(int(stats.FailedTaskCount))})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+
+	return writeRows(w, format, []string{"JOB", "INSTANCES", "ACTIVE", "PENDING", "FINISHED", "FAILED"}, rows)
+}
+
+// Render the quota of a role next to each kind of consumption.
+func WriteQuota(w io.Writer, quota *aurora.GetQuotaResult_, format OutputFormat) error {
+	if quota == nil {
+		return errors.New("No quota to write")
+	}
+
+	aggregates := []struct {
+		name      string
+		aggregate *aurora.ResourceAggregate
+	}{
+		{"quota", quota.Quota},
+		{"prod shared", quota.ProdSharedConsumption},
+		{"prod dedicated", quota.ProdDedicatedConsumption},
+		{"non-prod shared", quota.NonProdSharedConsumption},
+		{"non-prod dedicated", quota.NonProdDedicatedConsumption},
+	}
+
+	rows := make([][]string, 0, len(aggregates))
+	for _, entry := range aggregates {
+		if entry.aggregate == nil {
+			continue
+		}
+
+		rows = append(rows, []string{
+			entry.name,
+			strconv.FormatFloat(entry.aggregate.NumCpus, 'f', -1, 64),
+			strconv.FormatInt(entry.aggregate.RamMb, 10),
+			strconv.FormatInt(entry.aggregate.DiskMb, 10)})
+	}
+
+	return writeRows(w, format, []string{"RESOURCE", "CPU", "RAM MB", "DISK MB"}, rows)
+}
+
+func writeRows(w io.Writer, format OutputFormat, header []string, rows [][]string) error {
+	switch format {
+	case CSVFormat:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(header); err != nil {
+			return errors.Wrap(err, "Error writing CSV")
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return errors.Wrap(err, "Error writing CSV")
+		}
+		return nil
+	case TableFormat:
+		writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(writer, strings.Join(row, "\t"))
+		}
+		return errors.Wrap(writer.Flush(), "Error writing table")
+	default:
+		return errors.Errorf("Unknown output format %q", format)
+	}
+}
@@ -0,0 +1,129 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"strings"
+	"time"
+)
+
+// Operations consulted with the freeze calendar. Restarts in waves or with surge capacity are
+// authorized as RestartJob.
+var freezableOperations = map[string]bool{
+	"StartJobUpdate":  true,
+	"ResumeJobUpdate": true,
+	"RestartJob":      true,
+	"Promote":         true,
+	"ScheduleCronJob": true,
+	"DrainHosts":      true,
+}
+
+// Callback consulted before updates, restarts, promotions and host drains, e.g. backed by an
+// external maintenance calendar or change freeze list. now is read from the client's clock.
+// Returning an error vetoes the operation; the error is reported as the reason of the
+// FrozenError returned to the caller. Whether overridden deploy windows are let through is up to the
+// calendar, see Operation.Override.
+type FreezeCalendar func(op *Operation, now time.Time) error
+
+// Returned when the freeze calendar vetoes an operation.
+type FrozenError struct {
+	Operation string
+
+	// Job the operation was on, nil for host drains.
+	JobKey *aurora.JobKey
+
+	// Hosts to drain, empty for job operations.
+	Hosts []string
+
+	Reason error
+}
+
+func (e *FrozenError) Error() string {
+	target := strings.Join(e.Hosts, ", ")
+	if e.JobKey != nil {
+		target = e.JobKey.Role + "/" + e.JobKey.Environment + "/" + e.JobKey.Name
+	}
+	return fmt.Sprintf("%s on %s vetoed by the freeze calendar: %v", e.Operation, target, e.Reason)
+}
+
+func (e *FrozenError) Cause() error {
+	return e.Reason
+}
+
+// Whether err, or one it wraps, is a FrozenError.
+func IsFrozen(err error) bool {
+	for _, e := range errorChain(err) {
+		if _, ok := e.(*FrozenError); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Consult the calendar before starting updates, restarting and promoting jobs and draining hosts.
+func (r *RealisClient) SetFreezeCalendar(calendar FreezeCalendar) {
+	r.freeze = calendar
+}
+
+func (r *RealisClient) checkFreeze(op *Operation) error {
+	if r.freeze == nil || !freezableOperations[op.Name] {
+		return nil
+	}
+
+	if err := r.freeze(op, r.clock.Now()); err != nil {
+		return &FrozenError{Operation: op.Name, JobKey: op.JobKey, Hosts: op.Hosts, Reason: err}
+	}
+	return nil
+}
+
+// Period during which updates, restarts, promotions and host drains are frozen.
+type Freeze struct {
+	Start time.Time
+	End   time.Time
+
+	// Environments frozen, every environment when empty. Host drains are frozen regardless.
+	Environments []string
+
+	Reason string
+}
+
+// Calendar vetoing operations during any of the freezes, e.g. loaded from a freeze list.
+func FreezeList(freezes ...Freeze) FreezeCalendar {
+	return func(op *Operation, now time.Time) error {
+		for _, freeze := range freezes {
+			if now.Before(freeze.Start) || !now.Before(freeze.End) {
+				continue
+			}
+
+			if op.JobKey != nil && len(freeze.Environments) > 0 && !containsString(freeze.Environments, op.JobKey.Environment) {
+				continue
+			}
+
+			return fmt.Errorf("frozen until %s: %s", freeze.End.Format(time.RFC3339), freeze.Reason)
+		}
+		return nil
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
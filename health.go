@@ -0,0 +1,103 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Result of probing the health endpoint of a single instance.
+type InstanceHealth struct {
+	InstanceId int32
+	Host       string
+	Port       int32
+	Healthy    bool
+	StatusCode int
+	Err        error
+}
+
+// Probe the HTTP health endpoint of every running instance of a job directly, bypassing
+// Aurora. The host and port of each instance are resolved from its assigned task using the
+// given named port. Useful as a pre-traffic check after a deploy.
+func (r *RealisClient) ProbeInstances(
+	key *aurora.JobKey,
+	portName string,
+	path string,
+	timeout time.Duration) ([]InstanceHealth, error) {
+
+	tasks, err := r.GetTasksWithoutConfigs(&aurora.TaskQuery{
+		Role:        key.Role,
+		Environment: key.Environment,
+		JobName:     key.Name,
+		Statuses:    runningStates()})
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to retrieve running instances")
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	client := &http.Client{Timeout: timeout}
+	results := make([]InstanceHealth, len(tasks))
+	var wg sync.WaitGroup
+
+	for i, task := range tasks {
+		assigned := task.AssignedTask
+		results[i] = InstanceHealth{InstanceId: assigned.InstanceId, Host: assigned.SlaveHost}
+
+		port, ok := assigned.AssignedPorts[portName]
+		if !ok {
+			results[i].Err = errors.Errorf("Port %q not assigned to instance", portName)
+			continue
+		}
+		results[i].Port = port
+
+		wg.Add(1)
+		go func(health *InstanceHealth) {
+			defer wg.Done()
+			probeInstance(client, health, path)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].InstanceId < results[j].InstanceId
+	})
+
+	return results, nil
+}
+
+func probeInstance(client *http.Client, health *InstanceHealth, path string) {
+	addr := net.JoinHostPort(health.Host, strconv.Itoa(int(health.Port)))
+
+	resp, err := client.Get("http://" + addr + path)
+	if err != nil {
+		health.Err = err
+		return
+	}
+	resp.Body.Close()
+
+	health.StatusCode = resp.StatusCode
+	health.Healthy = resp.StatusCode >= 200 && resp.StatusCode < 300
+}
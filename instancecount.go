@@ -0,0 +1,93 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Instance counts of a job as sent by WatchInstanceCount. Err is set instead when the
+// scheduler could not be queried.
+type InstanceCount struct {
+	JobKey  *aurora.JobKey
+	Active  int32
+	Pending int32
+	Time    time.Time
+	Err     error
+}
+
+// Send the active and pending instance counts of a job on the returned channel, first right
+// away and then every time they change, until stop is closed. Counts come from the role's job
+// summaries rather than from task payloads, keeping dashboards and capacity alarms cheap to
+// feed. Polling follows the client's polling policy, backing off while the counts are steady.
+// A job that does not exist, or no longer does, has zero instances. The channel is closed
+// once stop is closed; counts not received in time are replaced by the next ones.
+func (r *RealisClient) WatchInstanceCount(key *aurora.JobKey, stop <-chan struct{}) <-chan InstanceCount {
+	counts := make(chan InstanceCount, 1)
+
+	go func() {
+		defer close(counts)
+
+		poll := r.newPoller()
+		var last *InstanceCount
+
+		for {
+			count := r.instanceCount(key)
+			changed := last == nil || count.Err != nil || last.Err != nil ||
+				count.Active != last.Active || count.Pending != last.Pending
+
+			if changed {
+				// Drop a count the consumer has not picked up yet, the new one supersedes it.
+				select {
+				case <-counts:
+				default:
+				}
+				counts <- count
+				last = &count
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-r.clock.After(poll.next([2]int32{count.Active, count.Pending})):
+			}
+		}
+	}()
+
+	return counts
+}
+
+func (r *RealisClient) instanceCount(key *aurora.JobKey) InstanceCount {
+	count := InstanceCount{JobKey: key, Time: r.clock.Now()}
+
+	summaries, err := r.GetJobSummaries(key.Role)
+	if err != nil {
+		count.Err = errors.Wrap(err, "Unable to retrieve job summaries")
+		return count
+	}
+
+	for _, summary := range summaries {
+		if summary.Job == nil || summary.Job.Key == nil || *summary.Job.Key != *key {
+			continue
+		}
+		if summary.Stats != nil {
+			count.Active = summary.Stats.ActiveTaskCount
+			count.Pending = summary.Stats.PendingTaskCount
+		}
+	}
+	return count
+}
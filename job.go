@@ -26,6 +26,8 @@ type Job struct {
 	ramMb     *aurora.Resource
 	diskMb    *aurora.Resource
 	portCount int
+	disk      DiskSettings
+	revocable *bool
 }
 
 // Create a Job object with everything initialized.
@@ -46,6 +48,7 @@ func NewJob() *Job {
 	taskConfig.MesosFetcherUris = make(map[*aurora.MesosFetcherURI]bool)
 	taskConfig.Metadata = make(map[*aurora.Metadata]bool)
 	taskConfig.Constraints = make(map[*aurora.Constraint]bool)
+	taskConfig.TaskLinks = make(map[string]string)
 
 	//Resources
 	numCpus := aurora.NewResource()
@@ -57,7 +60,65 @@ func NewJob() *Job {
 	taskConfig.Resources[ramMb] = true
 	taskConfig.Resources[diskMb] = true
 
-	return &Job{jobConfig, numCpus, ramMb, diskMb, 0}
+	return &Job{jobConfig: jobConfig, numCpus: numCpus, ramMb: ramMb, diskMb: diskMb}
+}
+
+// Create a Job object around an existing job configuration, e.g. one returned by GetJobs. The
+// configuration is used as is, not copied.
+func NewJobFromConfig(jobConfig *aurora.JobConfiguration) *Job {
+	job := &Job{jobConfig: jobConfig}
+	taskConfig := jobConfig.TaskConfig
+
+	if taskConfig.Resources == nil {
+		taskConfig.Resources = make(map[*aurora.Resource]bool)
+	}
+	for resource := range taskConfig.Resources {
+		switch {
+		case resource.NumCpus != nil:
+			job.numCpus = resource
+		case resource.RamMb != nil:
+			job.ramMb = resource
+		case resource.DiskMb != nil:
+			job.diskMb = resource
+		case resource.NamedPort != nil:
+			job.portCount++
+		}
+	}
+
+	// Configurations from older schedulers only carry the deprecated resource fields.
+	if job.numCpus == nil {
+		job.numCpus = aurora.NewResource()
+		taskConfig.Resources[job.numCpus] = true
+		job.CPU(taskConfig.NumCpus)
+	}
+	if job.ramMb == nil {
+		job.ramMb = aurora.NewResource()
+		taskConfig.Resources[job.ramMb] = true
+		job.RAM(taskConfig.RamMb)
+	}
+	if job.diskMb == nil {
+		job.diskMb = aurora.NewResource()
+		taskConfig.Resources[job.diskMb] = true
+		job.Disk(taskConfig.DiskMb)
+	}
+
+	if taskConfig.MesosFetcherUris == nil {
+		taskConfig.MesosFetcherUris = make(map[*aurora.MesosFetcherURI]bool)
+	}
+	if taskConfig.Metadata == nil {
+		taskConfig.Metadata = make(map[*aurora.Metadata]bool)
+	}
+	if taskConfig.Constraints == nil {
+		taskConfig.Constraints = make(map[*aurora.Constraint]bool)
+	}
+	if taskConfig.TaskLinks == nil {
+		taskConfig.TaskLinks = make(map[string]string)
+	}
+	if taskConfig.ExecutorConfig == nil {
+		taskConfig.ExecutorConfig = aurora.NewExecutorConfig()
+	}
+
+	return job
 }
 
 // Set Job Key environment.
@@ -139,6 +200,16 @@ func (a *Job) JobKey() *aurora.JobKey {
 	return a.jobConfig.Key
 }
 
+// Job configuration sent to the scheduler.
+func (a *Job) JobConfig() *aurora.JobConfiguration {
+	return a.jobConfig
+}
+
+// Whether the job runs on a cron schedule, in which case it is scheduled instead of created.
+func (a *Job) IsCron() bool {
+	return a.jobConfig.CronSchedule != nil && *a.jobConfig.CronSchedule != ""
+}
+
 // Add URI to fetch using the mesos fetcher. Scheduler must have --enable_mesos_fetcher flag
 // enabled.
 func (a *Job) AddURI(value string, extract bool, cache bool) *Job {
@@ -161,6 +232,14 @@ func (a *Job) AddLabel(key string, value string) *Job {
 	return a
 }
 
+// Add a link shown next to the job's tasks in the Aurora UI, e.g. a health, metrics or
+// dashboard page. The scheduler expands %host%, %port:name%, %shard_id% and %task_id%
+// in the url for every task.
+func (a *Job) AddLink(name string, url string) *Job {
+	a.jobConfig.TaskConfig.TaskLinks[name] = url
+	return a
+}
+
 // Add a named port to the job configuration  These are random ports as it's
 // not currently possible to request specific ports using Aurora.
 func (a *Job) AddNamedPorts(names ...string) *Job {
@@ -0,0 +1,113 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"encoding/json"
+	"gen-go/apache/aurora"
+	"time"
+)
+
+// JSON layouts of the wrapper types. Field names are part of the API and must not change.
+
+type jsonJobKey struct {
+	Role        string `json:"role"`
+	Environment string `json:"environment"`
+	Name        string `json:"name"`
+}
+
+type jsonTaskEvent struct {
+	Time      time.Time `json:"time"`
+	Status    string    `json:"status"`
+	Message   string    `json:"message,omitempty"`
+	Scheduler string    `json:"scheduler,omitempty"`
+}
+
+type jsonTask struct {
+	ID           string           `json:"id"`
+	Job          *jsonJobKey      `json:"job,omitempty"`
+	Instance     int32            `json:"instance"`
+	Host         string           `json:"host,omitempty"`
+	AgentID      string           `json:"agent_id,omitempty"`
+	Status       string           `json:"status"`
+	FailureCount int32            `json:"failure_count"`
+	Ports        map[string]int32 `json:"ports,omitempty"`
+	LaunchedAt   *time.Time       `json:"launched_at,omitempty"`
+	Events       []jsonTaskEvent  `json:"events"`
+}
+
+type jsonUpdateSummary struct {
+	ID             string      `json:"id"`
+	Job            *jsonJobKey `json:"job"`
+	Status         string      `json:"status"`
+	User           string      `json:"user"`
+	CreatedAt      time.Time   `json:"created_at"`
+	LastModifiedAt time.Time   `json:"last_modified_at"`
+}
+
+func newJSONJobKey(key *aurora.JobKey) *jsonJobKey {
+	if key == nil {
+		return nil
+	}
+	return &jsonJobKey{Role: key.Role, Environment: key.Environment, Name: key.Name}
+}
+
+// Serialize the task as:
+//
+//	{"id": "...", "job": {"role": "...", "environment": "...", "name": "..."}, "instance": 0,
+//	 "host": "...", "agent_id": "...", "status": "RUNNING", "failure_count": 0,
+//	 "ports": {"http": 31000}, "launched_at": "2006-01-02T15:04:05Z",
+//	 "events": [{"time": "...", "status": "PENDING", "message": "...", "scheduler": "..."}]}
+func (t *Task) MarshalJSON() ([]byte, error) {
+	record := jsonTask{
+		ID:           t.ID(),
+		Job:          newJSONJobKey(t.JobKey()),
+		Instance:     t.InstanceID(),
+		Host:         t.Host(),
+		AgentID:      t.AgentID(),
+		Status:       t.Status().String(),
+		FailureCount: t.FailureCount(),
+		Ports:        t.Ports(),
+		Events:       []jsonTaskEvent{}}
+
+	if launched := t.LaunchedAt(); !launched.IsZero() {
+		launched = launched.UTC()
+		record.LaunchedAt = &launched
+	}
+
+	for _, event := range t.Events() {
+		record.Events = append(record.Events, jsonTaskEvent{
+			Time:      event.Time.UTC(),
+			Status:    event.Status.String(),
+			Message:   event.Message,
+			Scheduler: event.Scheduler})
+	}
+
+	return json.Marshal(record)
+}
+
+// Serialize the update summary as:
+//
+//	{"id": "...", "job": {"role": "...", "environment": "...", "name": "..."},
+//	 "status": "ROLLING_FORWARD", "user": "...", "created_at": "...", "last_modified_at": "..."}
+func (u *UpdateSummary) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonUpdateSummary{
+		ID:             u.ID(),
+		Job:            newJSONJobKey(u.JobKey()),
+		Status:         u.Status().String(),
+		User:           u.User(),
+		CreatedAt:      u.CreatedAt().UTC(),
+		LastModifiedAt: u.LastModifiedAt().UTC()})
+}
@@ -0,0 +1,92 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+)
+
+// Returned when a key is malformed, before anything is sent to the scheduler.
+type InvalidKeyError struct {
+	// Type of key, e.g. "job update key".
+	Kind   string
+	Reason string
+}
+
+func (e *InvalidKeyError) Error() string {
+	return fmt.Sprintf("Invalid %s: %s", e.Kind, e.Reason)
+}
+
+// Check that a job key has a role, environment and name.
+func ValidateJobKey(key *aurora.JobKey) error {
+	switch {
+	case key == nil:
+		return &InvalidKeyError{Kind: "job key", Reason: "key is nil"}
+	case key.Role == "":
+		return &InvalidKeyError{Kind: "job key", Reason: "role is empty"}
+	case key.Environment == "":
+		return &InvalidKeyError{Kind: "job key", Reason: "environment is empty"}
+	case key.Name == "":
+		return &InvalidKeyError{Kind: "job key", Reason: "name is empty"}
+	}
+	return nil
+}
+
+// Check that an instance key has a valid job key and a non negative instance id.
+func ValidateInstanceKey(key *aurora.InstanceKey) error {
+	if key == nil {
+		return &InvalidKeyError{Kind: "instance key", Reason: "key is nil"}
+	}
+	if err := ValidateJobKey(key.JobKey); err != nil {
+		return &InvalidKeyError{Kind: "instance key", Reason: err.Error()}
+	}
+	if key.InstanceId < 0 {
+		return &InvalidKeyError{Kind: "instance key", Reason: fmt.Sprintf("instance id %d is negative", key.InstanceId)}
+	}
+	return nil
+}
+
+// Check that a job update key has a valid job key and an id.
+func ValidateJobUpdateKey(key *aurora.JobUpdateKey) error {
+	if key == nil {
+		return &InvalidKeyError{Kind: "job update key", Reason: "key is nil"}
+	}
+	if err := ValidateJobKey(key.Job); err != nil {
+		return &InvalidKeyError{Kind: "job update key", Reason: err.Error()}
+	}
+	if key.ID == "" {
+		return &InvalidKeyError{Kind: "job update key", Reason: "id is empty"}
+	}
+	return nil
+}
+
+// Build an instance key, validating it.
+func NewInstanceKey(key *aurora.JobKey, instanceId int32) (*aurora.InstanceKey, error) {
+	instKey := &aurora.InstanceKey{JobKey: key, InstanceId: instanceId}
+	if err := ValidateInstanceKey(instKey); err != nil {
+		return nil, err
+	}
+	return instKey, nil
+}
+
+// Build a job update key, validating it.
+func NewJobUpdateKey(key *aurora.JobKey, updateId string) (*aurora.JobUpdateKey, error) {
+	updateKey := &aurora.JobUpdateKey{Job: key, ID: updateId}
+	if err := ValidateJobUpdateKey(updateKey); err != nil {
+		return nil, err
+	}
+	return updateKey, nil
+}
@@ -0,0 +1,129 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// What killing a job would affect, as computed by PlanKillJob.
+type KillPlan struct {
+	JobKey *aurora.JobKey
+
+	// Active instances and their tasks at planning time.
+	Instances []int32
+	TaskIds   []string
+
+	// Confirms the kill with KillJobWithPlan, valid as long as the job's active tasks remain
+	// the same.
+	Token string
+}
+
+func (p *KillPlan) String() string {
+	return fmt.Sprintf("Kill %s/%s/%s: %d instance(s) %v (token %s)",
+		p.JobKey.Role, p.JobKey.Environment, p.JobKey.Name, len(p.Instances), p.Instances, p.Token)
+}
+
+// Returned by KillJobWithPlan when the job's active tasks changed since the plan was made.
+type KillPlanMismatchError struct {
+	Token   string
+	Current *KillPlan
+}
+
+func (e *KillPlanMismatchError) Error() string {
+	return fmt.Sprintf("Job changed since kill plan %s was made, now %d active instance(s) %v",
+		e.Token, len(e.Current.Instances), e.Current.Instances)
+}
+
+// First step of a two step kill: summarize the active instances of a job and return a token
+// to pass to KillJobWithPlan.
+func (r *RealisClient) PlanKillJob(key *aurora.JobKey) (*KillPlan, error) {
+	tasks, err := r.GetTasksWithoutConfigs(&aurora.TaskQuery{
+		Role:        key.Role,
+		Environment: key.Environment,
+		JobName:     key.Name,
+		Statuses:    aurora.ACTIVE_STATES})
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to retrieve active tasks")
+	}
+
+	plan := &KillPlan{JobKey: key}
+	for _, task := range tasks {
+		plan.Instances = append(plan.Instances, task.AssignedTask.InstanceId)
+		plan.TaskIds = append(plan.TaskIds, task.AssignedTask.TaskId)
+	}
+	sort.Slice(plan.Instances, func(i, j int) bool { return plan.Instances[i] < plan.Instances[j] })
+	sort.Strings(plan.TaskIds)
+
+	hash := sha1.New()
+	fmt.Fprintf(hash, "%s/%s/%s\n", key.Role, key.Environment, key.Name)
+	for _, id := range plan.TaskIds {
+		fmt.Fprintln(hash, id)
+	}
+	plan.Token = hex.EncodeToString(hash.Sum(nil))[:16]
+
+	return plan, nil
+}
+
+// Second step of a two step kill: kill the instances of the plan, only if the job's active
+// tasks are still the ones the token was issued for. Returns a *KillPlanMismatchError otherwise.
+func (r *RealisClient) KillJobWithPlan(key *aurora.JobKey, token string) (*aurora.Response, error) {
+	op := &Operation{Name: "KillJob", JobKey: key}
+	if err := r.authorize(op); err != nil {
+		return nil, err
+	}
+
+	fence := r.Fence()
+	current, err := r.PlanKillJob(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Token != token {
+		return nil, &KillPlanMismatchError{Token: token, Current: current}
+	}
+
+	if len(current.Instances) == 0 {
+		return nil, errors.New("No tasks in the Active state.")
+	}
+
+	instanceIds := make(map[int32]bool)
+	for _, id := range current.Instances {
+		instanceIds[id] = true
+	}
+
+	// The plan must have been checked against the scheduler the kill is sent to.
+	if err := fence.Check(); err != nil {
+		return nil, err
+	}
+
+	response, err := r.thriftCallAs(op, "KillTasks", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.KillTasks(key, instanceIds)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error sending Kill command to Aurora Scheduler.")
+	}
+
+	if isUpdateInProgress(response) {
+		return response, r.updateInProgressError(key, response)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,131 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"net/http"
+	"time"
+)
+
+// ZooKeeper path under which the leading scheduler announces itself by default.
+const DefaultSchedulerZKPath = "/aurora/scheduler"
+
+// Returns the URL of the current leading scheduler.
+type LeaderResolver func() (string, error)
+
+// Resolve the leader from the serverset the leading scheduler announces itself to.
+func ZKLeaderResolver(zkServers []string, path string, timeout time.Duration) LeaderResolver {
+	return func() (string, error) {
+		instances, err := ReadServerset(zkServers, path, timeout)
+		if err != nil {
+			return "", err
+		}
+		if len(instances) == 0 {
+			return "", errors.Errorf("No scheduler announced under %s", path)
+		}
+
+		endpoint := instances[0].ServiceEndpoint
+		return fmt.Sprintf("http://%s:%d", endpoint.Host, endpoint.Port), nil
+	}
+}
+
+// Resolve the leader among a list of schedulers by asking each one, in order, whether it leads
+// through its /leaderhealth endpoint.
+func SchedulerListResolver(urls []string, timeout time.Duration) LeaderResolver {
+	client := &http.Client{Timeout: timeout}
+
+	return func() (string, error) {
+		for _, addr := range urls {
+			base, err := normalizeURL(addr, defaultAPIPath)
+			if err != nil {
+				return "", err
+			}
+
+			resp, err := client.Get(base + "/leaderhealth")
+			if err != nil {
+				continue
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode == http.StatusOK {
+				return base, nil
+			}
+		}
+		return "", errors.Errorf("None of the %d scheduler(s) is the leader", len(urls))
+	}
+}
+
+// Re-resolve the leader and re-open the transport when a call fails because the scheduler
+// can't be reached, e.g. after a leader change, then retry the call once against the new
+// leader. A call whose request reached the old leader before the connection broke may be
+// applied twice.
+func WithLeaderResolver(config *RealisConfig, resolver LeaderResolver) error {
+	if config.httpClient == nil {
+		return errors.New("Leader failover requires an HTTP transport")
+	}
+
+	config.resolver = resolver
+	return nil
+}
+
+// Invoke an RPC, retrying it once against a newly resolved leader if the scheduler couldn't
+// be reached and a leader resolver is configured.
+func (r *RealisClient) withFailover(invoke func() (*aurora.Response, error)) (*aurora.Response, error) {
+	response, err := invoke()
+	if err == nil || r.config.resolver == nil || !schedulerUnavailable(err) {
+		return response, err
+	}
+
+	if reopenErr := r.reopen(); reopenErr != nil {
+		return response, errors.Wrapf(err, "Leader failover failed: %v", reopenErr)
+	}
+
+	return invoke()
+}
+
+// Resolve the leader and replace the thrift transport and clients with ones pointing to it.
+// The HTTP client, with its cookies and round trippers, and the transport headers are kept.
+func (r *RealisClient) reopen() error {
+	leader, err := r.config.resolver()
+	if err != nil {
+		return errors.Wrap(err, "Unable to resolve the leading scheduler")
+	}
+
+	url, err := normalizeURL(leader, r.config.apiPath)
+	if err != nil {
+		return err
+	}
+
+	r.poolLock.Lock()
+	defer r.poolLock.Unlock()
+
+	trans, err := r.newTransport(url)
+	if err != nil {
+		return err
+	}
+
+	r.resetConnections()
+	r.config.transport.Close()
+
+	previousURL := r.config.url
+	r.config.transport = trans
+	r.config.url = url
+
+	r.emit(Event{Type: LeaderFailoverEvent, Message: fmt.Sprintf("Reconnected from %s to %s", previousURL, url), Payload: url})
+	return nil
+}
@@ -0,0 +1,169 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/pkg/errors"
+)
+
+// Limits applied by the thrift layer while decoding scheduler responses. Unset limits don't
+// apply, so responses of any size are read whole, which full configuration queries on big
+// roles need.
+type ThriftLimits struct {
+	// Bytes read for a single response message. Over HTTP each message travels as a single
+	// frame, the response body, so this also bounds frames.
+	MaxMessageSize int64
+
+	// Elements in any single list, set or map of a response.
+	MaxContainerSize int
+}
+
+// Returned when a scheduler response exceeds one of the limits set with WithThriftLimits.
+type ThriftLimitError struct {
+	// Either "message size" or "container size".
+	Limit string
+	Max   int64
+}
+
+func (e *ThriftLimitError) Error() string {
+	return fmt.Sprintf("scheduler response exceeds the thrift %s limit of %d, narrow the query "+
+		"or raise the limit with WithThriftLimits", e.Limit, e.Max)
+}
+
+// Whether err was caused by a response exceeding one of the configured thrift limits.
+func IsThriftLimitExceeded(err error) bool {
+	for _, e := range errorChain(err) {
+		if _, ok := e.(*ThriftLimitError); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Set the thrift limits applied to scheduler responses. Calls whose response exceeds a limit
+// fail with a *ThriftLimitError instead of a decoding error from deep within thrift.
+func WithThriftLimits(config *RealisConfig, limits ThriftLimits) error {
+	if config == nil || config.transport == nil {
+		return errors.New("Configuration has no transport")
+	}
+
+	if limits.MaxMessageSize < 0 || limits.MaxContainerSize < 0 {
+		return errors.New("Thrift limits cannot be negative")
+	}
+
+	config.limits = limits
+	return nil
+}
+
+// Transport of a connection counting the bytes of each response against the limits. A
+// response starts after the request is flushed.
+type limitedTransport struct {
+	thrift.TTransport
+	limits ThriftLimits
+	read   int64
+	err    *ThriftLimitError
+}
+
+func (t *limitedTransport) Flush() error {
+	t.read = 0
+	t.err = nil
+	return t.TTransport.Flush()
+}
+
+func (t *limitedTransport) Read(p []byte) (int, error) {
+	max := t.limits.MaxMessageSize
+	if max <= 0 {
+		return t.TTransport.Read(p)
+	}
+
+	if t.read > max {
+		return 0, t.exceeded("message size", max)
+	}
+
+	// Read one byte past the limit to tell a message of exactly max bytes from a larger one.
+	if int64(len(p)) > max-t.read+1 {
+		p = p[:max-t.read+1]
+	}
+
+	n, err := t.TTransport.Read(p)
+	t.read += int64(n)
+	if t.read > max {
+		return n, t.exceeded("message size", max)
+	}
+	return n, err
+}
+
+func (t *limitedTransport) exceeded(limit string, max int64) error {
+	if t.err == nil {
+		t.err = &ThriftLimitError{Limit: limit, Max: max}
+	}
+	return thrift.NewTProtocolExceptionWithType(thrift.SIZE_LIMIT, t.err)
+}
+
+// The limit exceeded by the last response, if any.
+func (t *limitedTransport) exceededLimit() error {
+	if t == nil || t.err == nil {
+		return nil
+	}
+	return t.err
+}
+
+// Protocols checking container sizes, reporting violations through the connection's transport.
+type limitedProtocolFactory struct {
+	factory   thrift.TProtocolFactory
+	transport *limitedTransport
+}
+
+func (f *limitedProtocolFactory) GetProtocol(trans thrift.TTransport) thrift.TProtocol {
+	return &limitedProtocol{TProtocol: f.factory.GetProtocol(trans), transport: f.transport}
+}
+
+type limitedProtocol struct {
+	thrift.TProtocol
+	transport *limitedTransport
+}
+
+func (p *limitedProtocol) ReadMapBegin() (thrift.TType, thrift.TType, int, error) {
+	keyType, valueType, size, err := p.TProtocol.ReadMapBegin()
+	if err == nil {
+		err = p.checkContainer(size)
+	}
+	return keyType, valueType, size, err
+}
+
+func (p *limitedProtocol) ReadListBegin() (thrift.TType, int, error) {
+	elemType, size, err := p.TProtocol.ReadListBegin()
+	if err == nil {
+		err = p.checkContainer(size)
+	}
+	return elemType, size, err
+}
+
+func (p *limitedProtocol) ReadSetBegin() (thrift.TType, int, error) {
+	elemType, size, err := p.TProtocol.ReadSetBegin()
+	if err == nil {
+		err = p.checkContainer(size)
+	}
+	return elemType, size, err
+}
+
+func (p *limitedProtocol) checkContainer(size int) error {
+	if max := p.transport.limits.MaxContainerSize; max > 0 && size > max {
+		return p.transport.exceeded("container size", int64(max))
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"github.com/samuel/go-zookeeper/zk"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Root ZooKeeper path under which update locks are created by default.
+const DefaultUpdateLockRoot = "/gorealis/update-locks"
+
+// Exclusive claim on updating a job, shared by every deploy agent using the same ZooKeeper
+// ensemble and root. The lock is held by an ephemeral node so it goes away on its own if
+// the holder dies, or when its ZooKeeper session expires.
+type UpdateLock struct {
+	conn *zk.Conn
+	path string
+
+	lost     chan struct{}
+	lostOnce sync.Once
+}
+
+// Returned when another agent holds the update lock of a job.
+type UpdateLockHeldError struct {
+	JobKey *aurora.JobKey
+	Holder string
+}
+
+func (e *UpdateLockHeldError) Error() string {
+	return fmt.Sprintf("update lock for %s/%s/%s is held by %s",
+		e.JobKey.Role, e.JobKey.Environment, e.JobKey.Name, e.Holder)
+}
+
+// Take the update lock of a job, recording owner as the holder. Fails with an
+// *UpdateLockHeldError if another agent holds it.
+func AcquireUpdateLock(zkServers []string, root string, key *aurora.JobKey, owner string,
+	timeout time.Duration) (*UpdateLock, error) {
+
+	lock := &UpdateLock{lost: make(chan struct{})}
+	conn, err := connectZooKeeper(zkServers, timeout, func(event zk.Event) {
+		if event.State == zk.StateExpired {
+			lock.lostOnce.Do(func() { close(lock.lost) })
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Locks are laid out like serversets, one node per job.
+	path := ServersetPath(root, key)
+	if err := createParents(conn, path); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	_, err = conn.Create(path, []byte(owner), zk.FlagEphemeral, zk.WorldACL(zk.PermAll))
+	if err == zk.ErrNodeExists {
+		defer conn.Close()
+
+		holder, _, err := conn.Get(path)
+		if err == zk.ErrNoNode {
+			// Released while we were looking, let the caller retry.
+			holder = []byte("unknown (just released)")
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "Error reading update lock %s", path)
+		}
+		return nil, &UpdateLockHeldError{JobKey: key, Holder: string(holder)}
+	} else if err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "Error creating update lock %s", path)
+	}
+
+	lock.conn, lock.path = conn, path
+	return lock, nil
+}
+
+// Closed when the ZooKeeper session holding the lock expires. The lock is lost from then on
+// and another agent may take it.
+func (l *UpdateLock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Give up the lock so other agents can update the job. The lock node is only deleted while
+// this session still owns it, a lost lock may already belong to another agent.
+func (l *UpdateLock) Release() error {
+	defer l.conn.Close()
+
+	_, stat, err := l.conn.Get(l.path)
+	if err == zk.ErrNoNode {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "Error reading update lock %s", l.path)
+	}
+
+	if stat.EphemeralOwner != l.conn.SessionID() {
+		return errors.Errorf("Update lock %s was lost when its ZooKeeper session expired", l.path)
+	}
+
+	if err := l.conn.Delete(l.path, stat.Version); err != nil && err != zk.ErrNoNode {
+		return errors.Wrapf(err, "Error releasing update lock %s", l.path)
+	}
+	return nil
+}
+
+// Create the persistent ancestors of path that don't exist yet.
+func createParents(conn *zk.Conn, path string) error {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	node := ""
+	for _, part := range parts[:len(parts)-1] {
+		node += "/" + part
+		_, err := conn.Create(node, nil, 0, zk.WorldACL(zk.PermAll))
+		if err != nil && err != zk.ErrNodeExists {
+			return errors.Wrapf(err, "Error creating ZooKeeper node %s", node)
+		}
+	}
+	return nil
+}
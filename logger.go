@@ -0,0 +1,94 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"log"
+)
+
+// Destination of the client's log messages, e.g. an adapter to the application's logger.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// Logger discarding every message, the default.
+type NoopLogger struct{}
+
+func (NoopLogger) Debugf(format string, args ...interface{}) {}
+func (NoopLogger) Infof(format string, args ...interface{})  {}
+func (NoopLogger) Errorf(format string, args ...interface{}) {}
+
+// Logger writing to a standard library logger, log.Printf's logger when nil, with the level
+// as prefix. Debug messages are dropped unless debug is set.
+func StdLogger(logger *log.Logger, debug bool) Logger {
+	return &stdLogger{logger: logger, debug: debug}
+}
+
+type stdLogger struct {
+	logger *log.Logger
+	debug  bool
+}
+
+func (l *stdLogger) printf(format string, args ...interface{}) {
+	if l.logger == nil {
+		log.Printf(format, args...)
+		return
+	}
+	l.logger.Printf(format, args...)
+}
+
+func (l *stdLogger) Debugf(format string, args ...interface{}) {
+	if l.debug {
+		l.printf("DEBUG "+format, args...)
+	}
+}
+
+func (l *stdLogger) Infof(format string, args ...interface{}) {
+	l.printf("INFO "+format, args...)
+}
+
+func (l *stdLogger) Errorf(format string, args ...interface{}) {
+	l.printf("ERROR "+format, args...)
+}
+
+// Log what the client does: failed calls, retries and failovers as errors and infos, and with
+// debugRPCs set, every call with its duration and response code at debug level. Should be set
+// before the client is shared between goroutines.
+func (r *RealisClient) SetLogger(logger Logger, debugRPCs bool) {
+	if logger == nil {
+		logger = NoopLogger{}
+	}
+	r.logger = logger
+	r.debugRPCs = debugRPCs
+}
+
+// Log an event published by the client.
+func (r *RealisClient) logEvent(event Event) {
+	switch event.Type {
+	case RPCEndEvent:
+		info, _ := event.Payload.(RPCInfo)
+		if info.Err != nil {
+			r.logger.Errorf("%s failed after %v: %v", info.Name, info.Duration, info.Err)
+		} else if r.debugRPCs {
+			r.logger.Debugf("%s took %v: %s", info.Name, info.Duration, info.ResponseCode)
+		}
+	case RPCRetryEvent, LeaderFailoverEvent, KillEscalatedEvent, AutoscaleEvent, StuckUpdateEvent:
+		r.logger.Infof("%s", event.Message)
+	case SchedulerStatsErrorEvent, AutoscaleErrorEvent, WatchdogErrorEvent:
+		r.logger.Errorf("%s", event.Message)
+	}
+}
@@ -0,0 +1,145 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Describes the login flow required by clusters that authenticate users through a login
+// endpoint before allowing /api calls.
+type LoginConfig struct {
+	// Path of the login endpoint relative to the scheduler URL, e.g. "/login".
+	Path string
+
+	// Form fields posted to the login endpoint, e.g. username and password.
+	Form url.Values
+
+	// Extra headers sent with the login request, e.g. a token for token based logins.
+	Headers map[string]string
+}
+
+// Perform the login flow and keep the resulting session cookies in the configuration's jar.
+// Calls rejected because the session expired trigger a new login followed by a retry.
+func EnableLogin(config *RealisConfig, login LoginConfig) error {
+	if config.httpClient == nil || config.httpClient.Jar == nil {
+		return errors.New("Login requires an HTTP transport with a cookie jar")
+	}
+
+	if config.httpClient.Transport == nil {
+		if _, err := baseTransport(config); err != nil {
+			return err
+		}
+	}
+	next := config.httpClient.Transport
+	httpClient := config.httpClient
+
+	trans := &loginTransport{
+		next:    next,
+		jar:     httpClient.Jar,
+		apiPath: config.apiPath,
+		login: func(schedulerURL string) error {
+			return performLogin(&http.Client{
+				Transport: next,
+				Jar:       httpClient.Jar,
+				Timeout:   httpClient.Timeout}, schedulerURL, login)
+		}}
+
+	if err := trans.login(config.url); err != nil {
+		return err
+	}
+
+	config.httpClient.Transport = trans
+	return nil
+}
+
+func performLogin(client *http.Client, schedulerURL string, login LoginConfig) error {
+	req, err := http.NewRequest("POST", schedulerURL+login.Path, strings.NewReader(login.Form.Encode()))
+	if err != nil {
+		return errors.Wrap(err, "Error creating login request")
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for key, value := range login.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "Error logging in to Aurora Scheduler")
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("Login to Aurora Scheduler failed: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// RoundTripper that logs in again and retries requests rejected because the session expired.
+// The login goes to the scheduler that rejected the request, which changes on failover.
+type loginTransport struct {
+	next    http.RoundTripper
+	jar     http.CookieJar
+	apiPath string
+	login   func(schedulerURL string) error
+	lock    sync.Mutex
+}
+
+func (t *loginTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || !sessionExpired(resp) || (req.Body != nil && req.GetBody == nil) {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	scheduler := *req.URL
+	scheduler.Path = strings.TrimSuffix(scheduler.Path, t.apiPath)
+	scheduler.RawPath, scheduler.RawQuery, scheduler.Fragment = "", "", ""
+
+	t.lock.Lock()
+	err = t.login(scheduler.String())
+	t.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Cookies were attached by the client before reaching the transport, replace them
+	// with the ones from the new session.
+	retry := new(http.Request)
+	*retry = *req
+	retry.Header = req.Header.Clone()
+	retry.Header.Del("Cookie")
+	for _, cookie := range t.jar.Cookies(req.URL) {
+		retry.AddCookie(cookie)
+	}
+
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, errors.Wrap(err, "Error rewinding request body")
+		}
+	}
+
+	return t.next.RoundTrip(retry)
+}
+
+func sessionExpired(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden
+}
@@ -0,0 +1,206 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log of a Thermos process.
+type LogStream string
+
+const (
+	Stdout LogStream = "stdout"
+	Stderr LogStream = "stderr"
+)
+
+// Bytes of log shown before following it, about a screen of lines.
+const logTailBytes = 8 * 1024
+
+// Bytes read from the observer per request.
+const logChunkBytes = 64 * 1024
+
+// Lines of a process log streamed from the Thermos observer, see TailLogs.
+type LogTail struct {
+	lines chan string
+	stop  chan struct{}
+	once  sync.Once
+	err   error
+}
+
+// Lines of the log, without their line breaks. The channel is closed when the whole log was
+// read, unless following it, when the tail is stopped, or when reading it failed.
+func (t *LogTail) Lines() <-chan string {
+	return t.lines
+}
+
+// Why the lines channel was closed early, nil if the log was read whole or the tail stopped.
+// Only valid once the channel is closed.
+func (t *LogTail) Err() error {
+	return t.err
+}
+
+// Stop reading the log. Safe to call more than once.
+func (t *LogTail) Stop() {
+	t.once.Do(func() { close(t.stop) })
+}
+
+// Stream the stdout of a process of a task from the Thermos observer next to it, like
+// "aurora task logs". With follow set, the end of the log is sent followed by lines as they
+// are written, until the tail is stopped, like "tail -f".
+func (r *RealisClient) TailLogs(task *aurora.ScheduledTask, process string, follow bool) *LogTail {
+	return r.TailLogStream(task, process, Stdout, follow)
+}
+
+// Stream one of the logs of a process, see TailLogs.
+func (r *RealisClient) TailLogStream(task *aurora.ScheduledTask, process string, stream LogStream, follow bool) *LogTail {
+	tail := &LogTail{lines: make(chan string), stop: make(chan struct{})}
+
+	go func() {
+		defer close(tail.lines)
+		tail.err = r.tailLog(tail, task, process, stream, follow)
+	}()
+
+	return tail
+}
+
+// Port of the Thermos observers TailLogs reads from, DefaultObserverPort when not set.
+func (r *RealisClient) SetObserverPort(port int) {
+	r.settingsLock.Lock()
+	defer r.settingsLock.Unlock()
+	r.observerPort = port
+}
+
+type observerLogChunk struct {
+	Data   string `json:"data"`
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+}
+
+type observerProcess struct {
+	ProcessRun int `json:"process_run"`
+}
+
+func (r *RealisClient) tailLog(tail *LogTail, task *aurora.ScheduledTask, process string, stream LogStream, follow bool) error {
+	if task.AssignedTask == nil || task.AssignedTask.SlaveHost == "" {
+		return errors.New("Task is not assigned to an agent")
+	}
+
+	r.settingsLock.RLock()
+	port := r.observerPort
+	r.settingsLock.RUnlock()
+	if port == 0 {
+		port = DefaultObserverPort
+	}
+	observer := "http://" + net.JoinHostPort(task.AssignedTask.SlaveHost, strconv.Itoa(port))
+	taskId := url.PathEscape(task.AssignedTask.TaskId)
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	var proc observerProcess
+	if err := getObserverJSON(client, observer+"/j/process/"+taskId+"/"+url.PathEscape(process), &proc); err != nil {
+		return errors.Wrapf(err, "Error looking up process %s", process)
+	}
+
+	logdata := observer + "/logdata/" + taskId + "/" + url.PathEscape(process) + "/" +
+		strconv.Itoa(proc.ProcessRun) + "/" + string(stream)
+
+	var offset int64
+	skipFirst := false
+	if follow {
+		// Offset -1 tells the size of the log.
+		var end observerLogChunk
+		if err := getObserverJSON(client, logdata+"?offset=-1", &end); err != nil {
+			return err
+		}
+		if end.Offset > logTailBytes {
+			offset = end.Offset - logTailBytes
+			skipFirst = true
+		}
+	}
+
+	poll := r.newPoller()
+	var partial string
+
+	for {
+		var chunk observerLogChunk
+		query := "?offset=" + strconv.FormatInt(offset, 10) + "&length=" + strconv.Itoa(logChunkBytes)
+		if err := getObserverJSON(client, logdata+query, &chunk); err != nil {
+			return err
+		}
+		offset += chunk.Length
+
+		lines := strings.Split(partial+chunk.Data, "\n")
+		partial = lines[len(lines)-1]
+		for _, line := range lines[:len(lines)-1] {
+			// Starting in the middle of the log, the first line is likely cut.
+			if skipFirst {
+				skipFirst = false
+				continue
+			}
+
+			select {
+			case tail.lines <- line:
+			case <-tail.stop:
+				return nil
+			}
+		}
+
+		if chunk.Length > 0 {
+			poll.next(offset)
+			continue
+		}
+
+		if !follow {
+			if partial != "" {
+				select {
+				case tail.lines <- partial:
+				case <-tail.stop:
+				}
+			}
+			return nil
+		}
+
+		select {
+		case <-tail.stop:
+			return nil
+		case <-r.clock.After(poll.next(offset)):
+		}
+	}
+}
+
+func getObserverJSON(client *http.Client, endpoint string, v interface{}) error {
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return errors.Wrap(err, "Error querying Thermos observer")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("Thermos observer returned %s for %s", resp.Status, endpoint)
+	}
+
+	if err := decodeJSON(resp.Body, v); err != nil {
+		return errors.Wrap(err, "Error decoding Thermos observer response")
+	}
+	return nil
+}
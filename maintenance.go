@@ -0,0 +1,155 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+)
+
+// Retrieve the maintenance mode of a set of hosts.
+func (r *RealisClient) MaintenanceStatus(hosts ...string) (map[string]aurora.MaintenanceMode, error) {
+	response, err := r.thriftCall("MaintenanceStatus", func(conn *connection) (*aurora.Response, error) {
+		return conn.adminClient.MaintenanceStatus(hostSet(hosts))
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for maintenance status")
+	}
+
+	statuses := make(map[string]aurora.MaintenanceMode)
+	if response.Result_ != nil && response.Result_.MaintenanceStatusResult_ != nil {
+		for status := range response.Result_.MaintenanceStatusResult_.Statuses {
+			statuses[status.Host] = status.Mode
+		}
+	}
+
+	return statuses, nil
+}
+
+// Ask the scheduler to move the tasks off the hosts and put them in DRAINED mode once empty.
+// Consults the freeze calendar first.
+func (r *RealisClient) DrainHosts(hosts ...string) (*aurora.Response, error) {
+	if len(hosts) == 0 {
+		return nil, errors.New("No hosts to drain")
+	}
+
+	if err := r.checkFreeze(&Operation{Name: "DrainHosts", Hosts: hosts}); err != nil {
+		return nil, err
+	}
+
+	response, err := r.thriftCall("DrainHosts", func(conn *connection) (*aurora.Response, error) {
+		return conn.adminClient.DrainHosts(hostSet(hosts))
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error sending DrainHosts command to Aurora Scheduler")
+	}
+
+	return response, nil
+}
+
+// Take the hosts out of maintenance, letting the scheduler place tasks on them again.
+func (r *RealisClient) EndMaintenance(hosts ...string) (*aurora.Response, error) {
+	if len(hosts) == 0 {
+		return nil, errors.New("No hosts to end maintenance on")
+	}
+
+	response, err := r.thriftCall("EndMaintenance", func(conn *connection) (*aurora.Response, error) {
+		return conn.adminClient.EndMaintenance(hostSet(hosts))
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error sending EndMaintenance command to Aurora Scheduler")
+	}
+
+	return response, nil
+}
+
+func hostSet(hosts []string) *aurora.Hosts {
+	hostNames := make(map[string]bool)
+	for _, host := range hosts {
+		hostNames[host] = true
+	}
+	return &aurora.Hosts{HostNames: hostNames}
+}
+
+// Returned when a job's hosts are too busy with maintenance for an update to start.
+type MaintenanceGateError struct {
+	JobKey        *aurora.JobKey
+	Hosts         int
+	DrainingHosts []string
+	MaxFraction   float64
+}
+
+func (e *MaintenanceGateError) Error() string {
+	return fmt.Sprintf("%d of %d hosts of %s/%s/%s are draining or drained, more than the %.0f%% allowed",
+		len(e.DrainingHosts), e.Hosts, e.JobKey.Role, e.JobKey.Environment, e.JobKey.Name, e.MaxFraction*100)
+}
+
+// Check that no more than maxFraction of the hosts running the job are DRAINING or DRAINED,
+// returning a *MaintenanceGateError otherwise.
+func (r *RealisClient) CheckMaintenanceGate(key *aurora.JobKey, maxFraction float64) error {
+	tasks, err := r.GetTasksWithoutConfigs(&aurora.TaskQuery{
+		Role:        key.Role,
+		Environment: key.Environment,
+		JobName:     key.Name,
+		Statuses:    aurora.SLAVE_ASSIGNED_STATES})
+	if err != nil {
+		return errors.Wrap(err, "Unable to retrieve the hosts of the job")
+	}
+
+	hosts := make(map[string]bool)
+	for _, task := range tasks {
+		hosts[task.AssignedTask.SlaveHost] = true
+	}
+
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	hostNames := make([]string, 0, len(hosts))
+	for host := range hosts {
+		hostNames = append(hostNames, host)
+	}
+
+	statuses, err := r.MaintenanceStatus(hostNames...)
+	if err != nil {
+		return err
+	}
+
+	var draining []string
+	for host, mode := range statuses {
+		if mode == aurora.MaintenanceMode_DRAINING || mode == aurora.MaintenanceMode_DRAINED {
+			draining = append(draining, host)
+		}
+	}
+
+	if float64(len(draining)) > maxFraction*float64(len(hosts)) {
+		return &MaintenanceGateError{JobKey: key, Hosts: len(hosts), DrainingHosts: draining, MaxFraction: maxFraction}
+	}
+
+	return nil
+}
+
+// Policy refusing to start updates while more than maxFraction of the job's hosts are in
+// maintenance, so updates don't collide with host drains.
+func (r *RealisClient) MaintenanceGate(maxFraction float64) Policy {
+	return func(op *Operation) error {
+		if op.Name != "StartJobUpdate" {
+			return nil
+		}
+		return r.CheckMaintenanceGate(op.JobKey, maxFraction)
+	}
+}
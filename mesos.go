@@ -0,0 +1,97 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Port on which Mesos agents serve their HTTP API by default.
+const DefaultAgentPort = 5051
+
+// Resource usage of a task's executor container as reported by the Mesos agent.
+type TaskUsage struct {
+	TaskId             string
+	Host               string
+	Timestamp          float64 `json:"timestamp"`
+	CPUsUserTimeSecs   float64 `json:"cpus_user_time_secs"`
+	CPUsSystemTimeSecs float64 `json:"cpus_system_time_secs"`
+	CPUsLimit          float64 `json:"cpus_limit"`
+	MemRSSBytes        int64   `json:"mem_rss_bytes"`
+	MemLimitBytes      int64   `json:"mem_limit_bytes"`
+}
+
+type agentStatistics struct {
+	ExecutorId string    `json:"executor_id"`
+	Statistics TaskUsage `json:"statistics"`
+}
+
+// Query the /monitor/statistics endpoint of the agent running the task for the actual cpu and
+// memory usage of its container.
+func TaskResourceUsage(task *aurora.ScheduledTask, agentPort int, timeout time.Duration) (*TaskUsage, error) {
+	if task.AssignedTask == nil || task.AssignedTask.SlaveHost == "" {
+		return nil, errors.New("Task is not assigned to an agent")
+	}
+
+	taskId := task.AssignedTask.TaskId
+	host := task.AssignedTask.SlaveHost
+	url := "http://" + net.JoinHostPort(host, strconv.Itoa(agentPort)) + "/monitor/statistics"
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error querying agent %s", host)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Agent %s returned %s", host, resp.Status)
+	}
+
+	var executors []agentStatistics
+	if err := decodeJSON(resp.Body, &executors); err != nil {
+		return nil, errors.Wrapf(err, "Error decoding statistics from agent %s", host)
+	}
+
+	// Aurora names the executor of a task after its task id, e.g. thermos-<task id>.
+	for _, executor := range executors {
+		if strings.HasSuffix(executor.ExecutorId, taskId) {
+			usage := executor.Statistics
+			usage.TaskId = taskId
+			usage.Host = host
+			return &usage, nil
+		}
+	}
+
+	return nil, errors.Errorf("No statistics for task %s on agent %s", taskId, host)
+}
+
+// Average number of cores used between two samples of the same task.
+func CPUUsage(previous *TaskUsage, current *TaskUsage) float64 {
+	elapsed := current.Timestamp - previous.Timestamp
+	if elapsed <= 0 {
+		return 0
+	}
+
+	used := (current.CPUsUserTimeSecs + current.CPUsSystemTimeSecs) -
+		(previous.CPUsUserTimeSecs + previous.CPUsSystemTimeSecs)
+	return used / elapsed
+}
@@ -0,0 +1,563 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// In-memory implementation of the realis.Realis interface for unit testing code that drives
+// Aurora without a live cluster.
+package mock
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"github.com/rdelval/gorealis"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Host tasks of the mock scheduler run on.
+const Host = "mock-host"
+
+// Scheduler held in memory. Tasks start RUNNING right away and updates finish as soon as
+// they start, unless HoldUpdates is set. Safe for concurrent use, results are copies of the
+// scheduler's state.
+type Client struct {
+	// Keep updates ROLLING_FORWARD until FinishUpdate is called, to test code watching them.
+	HoldUpdates bool
+
+	// Returned by GetTierConfigs and GetQuota, empty results when nil.
+	Tiers  *aurora.GetTierConfigResult_
+	Quotas map[string]*aurora.GetQuotaResult_
+
+	lock        sync.Mutex
+	jobs        map[string]*aurora.JobConfiguration
+	tasks       []*aurora.ScheduledTask
+	updates     map[string]*aurora.JobUpdateDetails
+	maintenance map[string]aurora.MaintenanceMode
+	nextId      int
+}
+
+var _ realis.Realis = (*Client)(nil)
+
+func New() *Client {
+	return &Client{
+		jobs:        make(map[string]*aurora.JobConfiguration),
+		updates:     make(map[string]*aurora.JobUpdateDetails),
+		maintenance: make(map[string]aurora.MaintenanceMode),
+		Quotas:      make(map[string]*aurora.GetQuotaResult_)}
+}
+
+func (c *Client) CreateJob(auroraJob *realis.Job) (*aurora.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	config := deepCopy(auroraJob.JobConfig()).(*aurora.JobConfiguration)
+	id := jobId(config.Key)
+	if _, ok := c.jobs[id]; ok {
+		return failure(aurora.ResponseCode_INVALID_REQUEST, "Job already exists: "+id), nil
+	}
+
+	c.jobs[id] = config
+	for i := int32(0); i < config.InstanceCount; i++ {
+		c.launch(config.TaskConfig, i)
+	}
+	return ok(nil), nil
+}
+
+func (c *Client) KillJob(key *aurora.JobKey) (*aurora.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	killed := c.kill(key, func(*aurora.ScheduledTask) bool { return true })
+	if killed == 0 {
+		return nil, errors.New("No tasks in the Active state.")
+	}
+
+	delete(c.jobs, jobId(key))
+	return ok(nil), nil
+}
+
+func (c *Client) KillInstance(key *aurora.JobKey, instanceId int32) (*aurora.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.kill(key, func(task *aurora.ScheduledTask) bool { return task.AssignedTask.InstanceId == instanceId })
+	return ok(nil), nil
+}
+
+func (c *Client) RestartJob(key *aurora.JobKey) (*aurora.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	active := c.active(key)
+	if len(active) == 0 {
+		return nil, errors.New("No tasks in the Active state.")
+	}
+
+	for _, task := range active {
+		c.transition(task, aurora.ScheduleStatus_KILLED)
+		c.launch(task.AssignedTask.Task, task.AssignedTask.InstanceId)
+	}
+	return ok(nil), nil
+}
+
+func (c *Client) AddInstances(instKey *aurora.InstanceKey, count int32) (*aurora.Response, error) {
+	if err := realis.ValidateInstanceKey(instKey); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var template *aurora.TaskConfig
+	next := int32(0)
+	for _, task := range c.active(instKey.JobKey) {
+		if task.AssignedTask.InstanceId == instKey.InstanceId {
+			template = task.AssignedTask.Task
+		}
+		if task.AssignedTask.InstanceId >= next {
+			next = task.AssignedTask.InstanceId + 1
+		}
+	}
+	if template == nil {
+		return failure(aurora.ResponseCode_INVALID_REQUEST, fmt.Sprintf("Instance %d is not active", instKey.InstanceId)), nil
+	}
+
+	for i := int32(0); i < count; i++ {
+		c.launch(template, next+i)
+	}
+	if config, ok := c.jobs[jobId(instKey.JobKey)]; ok {
+		config.InstanceCount += count
+	}
+	return ok(nil), nil
+}
+
+func (c *Client) RemoveInstances(key *aurora.JobKey, count int32) (*aurora.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	active := c.active(key)
+	if len(active) < int(count) {
+		return nil, errors.New("Not enough instances to kill.")
+	}
+
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].AssignedTask.InstanceId > active[j].AssignedTask.InstanceId
+	})
+	for _, task := range active[:count] {
+		c.transition(task, aurora.ScheduleStatus_KILLED)
+	}
+	if config, ok := c.jobs[jobId(key)]; ok {
+		config.InstanceCount -= count
+	}
+	return ok(nil), nil
+}
+
+func (c *Client) StartJobUpdate(updateJob *realis.UpdateJob, message string) (*aurora.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	req := deepCopy(updateJob.Request()).(*aurora.JobUpdateRequest)
+	key := updateJob.JobKey()
+
+	for _, details := range c.updates {
+		summary := details.Update.Summary
+		if jobId(summary.Key.Job) == jobId(key) && !realis.IsUpdateTerminal(summary.State.Status) {
+			return failure(aurora.ResponseCode_INVALID_REQUEST, "Job has an active update"), nil
+		}
+	}
+
+	c.nextId++
+	updateKey := &aurora.JobUpdateKey{Job: key, ID: fmt.Sprintf("update-%d", c.nextId)}
+	now := timestamp()
+	details := &aurora.JobUpdateDetails{
+		Update: &aurora.JobUpdate{
+			Summary: &aurora.JobUpdateSummary{
+				Key:   updateKey,
+				State: &aurora.JobUpdateState{CreatedTimestampMs: now}},
+			Instructions: &aurora.JobUpdateInstructions{
+				DesiredState: &aurora.InstanceTaskConfig{Task: req.TaskConfig},
+				Settings:     req.Settings}}}
+	c.updates[updateKey.ID] = details
+
+	c.setUpdateStatus(details, aurora.JobUpdateStatus_ROLLING_FORWARD, message)
+	if !c.HoldUpdates {
+		c.rollForward(details, req.InstanceCount)
+	}
+
+	return ok(&aurora.Result_{StartJobUpdateResult_: &aurora.StartJobUpdateResult_{Key: updateKey}}), nil
+}
+
+// Finish an update held because of HoldUpdates with the given status. Updates finishing as
+// ROLLED_FORWARD replace the job's tasks with the new configuration.
+func (c *Client) FinishUpdate(key *aurora.JobUpdateKey, status aurora.JobUpdateStatus) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	details, ok := c.updates[key.ID]
+	if !ok {
+		return errors.Errorf("Update %s not found", key.ID)
+	}
+
+	if status == aurora.JobUpdateStatus_ROLLED_FORWARD {
+		// The instance count is the one of the job unless the update changed it.
+		count := int32(len(c.active(key.Job)))
+		if config, ok := c.jobs[jobId(key.Job)]; ok {
+			count = config.InstanceCount
+		}
+		c.rollForward(details, count)
+		return nil
+	}
+
+	c.setUpdateStatus(details, status, "")
+	return nil
+}
+
+func (c *Client) AbortJobUpdate(key *aurora.JobKey, updateId string, message string) (*aurora.Response, error) {
+	return c.changeUpdate(updateId, func(aurora.JobUpdateStatus) aurora.JobUpdateStatus {
+		return aurora.JobUpdateStatus_ABORTED
+	}, message)
+}
+
+func (c *Client) PauseJobUpdate(key *aurora.JobUpdateKey, message string) (*aurora.Response, error) {
+	return c.changeUpdate(key.ID, func(status aurora.JobUpdateStatus) aurora.JobUpdateStatus {
+		if status == aurora.JobUpdateStatus_ROLLING_BACK || status == aurora.JobUpdateStatus_ROLL_BACK_PAUSED {
+			return aurora.JobUpdateStatus_ROLL_BACK_PAUSED
+		}
+		return aurora.JobUpdateStatus_ROLL_FORWARD_PAUSED
+	}, message)
+}
+
+func (c *Client) ResumeJobUpdate(key *aurora.JobUpdateKey, message string) (*aurora.Response, error) {
+	return c.changeUpdate(key.ID, func(status aurora.JobUpdateStatus) aurora.JobUpdateStatus {
+		if status == aurora.JobUpdateStatus_ROLLING_BACK || status == aurora.JobUpdateStatus_ROLL_BACK_PAUSED {
+			return aurora.JobUpdateStatus_ROLLING_BACK
+		}
+		return aurora.JobUpdateStatus_ROLLING_FORWARD
+	}, message)
+}
+
+func (c *Client) JobUpdateDetails(key *aurora.JobUpdateKey) (*aurora.JobUpdateDetails, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	details, ok := c.updates[key.ID]
+	if !ok {
+		return nil, errors.Errorf("Update %s not found", key.ID)
+	}
+	return deepCopy(details).(*aurora.JobUpdateDetails), nil
+}
+
+func (c *Client) GetTaskStatus(query *aurora.TaskQuery) ([]*aurora.ScheduledTask, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var tasks []*aurora.ScheduledTask
+	for _, task := range c.tasks {
+		if matches(query, task) {
+			tasks = append(tasks, task)
+		}
+	}
+	return deepCopy(tasks).([]*aurora.ScheduledTask), nil
+}
+
+func (c *Client) GetTasksWithoutConfigs(query *aurora.TaskQuery) ([]*aurora.ScheduledTask, error) {
+	return c.GetTaskStatus(query)
+}
+
+func (c *Client) GetJobs(role string) ([]*aurora.JobConfiguration, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var configs []*aurora.JobConfiguration
+	for _, config := range c.jobs {
+		if role == "" || config.Key.Role == role {
+			configs = append(configs, config)
+		}
+	}
+	return deepCopy(configs).([]*aurora.JobConfiguration), nil
+}
+
+func (c *Client) GetJobSummaries(role string) ([]*aurora.JobSummary, error) {
+	configs, _ := c.GetJobs(role)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	summaries := make([]*aurora.JobSummary, 0, len(configs))
+	for _, config := range configs {
+		summaries = append(summaries, &aurora.JobSummary{
+			Job:   config,
+			Stats: &aurora.JobStats{ActiveTaskCount: int32(len(c.active(config.Key)))}})
+	}
+	return summaries, nil
+}
+
+func (c *Client) GetQuota(role string) (*aurora.GetQuotaResult_, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if quota, ok := c.Quotas[role]; ok {
+		return quota, nil
+	}
+	return aurora.NewGetQuotaResult_(), nil
+}
+
+func (c *Client) GetTierConfigs() (*aurora.GetTierConfigResult_, error) {
+	if c.Tiers == nil {
+		return aurora.NewGetTierConfigResult_(), nil
+	}
+	return c.Tiers, nil
+}
+
+// Put a host in a maintenance mode, as reported by MaintenanceStatus.
+func (c *Client) SetMaintenanceMode(host string, mode aurora.MaintenanceMode) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.maintenance[host] = mode
+}
+
+func (c *Client) MaintenanceStatus(hosts ...string) (map[string]aurora.MaintenanceMode, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	statuses := make(map[string]aurora.MaintenanceMode)
+	for _, host := range hosts {
+		mode, ok := c.maintenance[host]
+		if !ok {
+			mode = aurora.MaintenanceMode_NONE
+		}
+		statuses[host] = mode
+	}
+	return statuses, nil
+}
+
+// Kill the active tasks on the hosts right away and put them in DRAINED mode.
+func (c *Client) DrainHosts(hosts ...string) (*aurora.Response, error) {
+	if len(hosts) == 0 {
+		return nil, errors.New("No hosts to drain")
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, host := range hosts {
+		for _, task := range c.tasks {
+			if task.AssignedTask.SlaveHost == host && aurora.ACTIVE_STATES[task.Status] {
+				c.transition(task, aurora.ScheduleStatus_KILLED)
+			}
+		}
+		c.maintenance[host] = aurora.MaintenanceMode_DRAINED
+	}
+	return ok(nil), nil
+}
+
+func (c *Client) EndMaintenance(hosts ...string) (*aurora.Response, error) {
+	if len(hosts) == 0 {
+		return nil, errors.New("No hosts to end maintenance on")
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, host := range hosts {
+		delete(c.maintenance, host)
+	}
+	return ok(nil), nil
+}
+
+func (c *Client) Close() error {
+	return nil
+}
+
+// Start a RUNNING task for an instance.
+func (c *Client) launch(config *aurora.TaskConfig, instanceId int32) {
+	c.nextId++
+	task := &aurora.ScheduledTask{
+		AssignedTask: &aurora.AssignedTask{
+			TaskId:     fmt.Sprintf("%s-%d-%d", jobId(config.Job), instanceId, c.nextId),
+			SlaveHost:  Host,
+			Task:       config,
+			InstanceId: instanceId}}
+	c.transition(task, aurora.ScheduleStatus_RUNNING)
+	c.tasks = append(c.tasks, task)
+}
+
+func (c *Client) transition(task *aurora.ScheduledTask, status aurora.ScheduleStatus) {
+	task.Status = status
+	task.TaskEvents = append(task.TaskEvents, &aurora.TaskEvent{Timestamp: timestamp(), Status: status})
+}
+
+// Kill the active tasks of a job selected by the filter, returning how many were killed.
+func (c *Client) kill(key *aurora.JobKey, filter func(*aurora.ScheduledTask) bool) int {
+	killed := 0
+	for _, task := range c.active(key) {
+		if filter(task) {
+			c.transition(task, aurora.ScheduleStatus_KILLED)
+			killed++
+		}
+	}
+	return killed
+}
+
+func (c *Client) active(key *aurora.JobKey) []*aurora.ScheduledTask {
+	var active []*aurora.ScheduledTask
+	for _, task := range c.tasks {
+		if jobId(task.AssignedTask.Task.Job) == jobId(key) && aurora.ACTIVE_STATES[task.Status] {
+			active = append(active, task)
+		}
+	}
+	return active
+}
+
+// Replace the job's tasks with instanceCount tasks of the update's configuration.
+func (c *Client) rollForward(details *aurora.JobUpdateDetails, instanceCount int32) {
+	key := details.Update.Summary.Key.Job
+	config := details.Update.Instructions.DesiredState.Task
+
+	for _, task := range c.active(key) {
+		c.transition(task, aurora.ScheduleStatus_KILLED)
+	}
+	for i := int32(0); i < instanceCount; i++ {
+		c.launch(config, i)
+	}
+
+	if job, ok := c.jobs[jobId(key)]; ok {
+		job.TaskConfig = config
+		job.InstanceCount = instanceCount
+	}
+	c.setUpdateStatus(details, aurora.JobUpdateStatus_ROLLED_FORWARD, "")
+}
+
+// Move an active update to the status next returns for its current one.
+func (c *Client) changeUpdate(updateId string, next func(aurora.JobUpdateStatus) aurora.JobUpdateStatus, message string) (*aurora.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	details, found := c.updates[updateId]
+	if !found {
+		return failure(aurora.ResponseCode_INVALID_REQUEST, "No such update "+updateId), nil
+	}
+	if realis.IsUpdateTerminal(details.Update.Summary.State.Status) {
+		return failure(aurora.ResponseCode_INVALID_REQUEST, "Update "+updateId+" is not active"), nil
+	}
+
+	c.setUpdateStatus(details, next(details.Update.Summary.State.Status), message)
+	return ok(nil), nil
+}
+
+func (c *Client) setUpdateStatus(details *aurora.JobUpdateDetails, status aurora.JobUpdateStatus, message string) {
+	now := timestamp()
+	state := details.Update.Summary.State
+	state.Status = status
+	state.LastModifiedTimestampMs = now
+
+	event := &aurora.JobUpdateEvent{Status: status, TimestampMs: now}
+	if message != "" {
+		event.Message = &message
+	}
+	details.UpdateEvents = append(details.UpdateEvents, event)
+}
+
+func matches(query *aurora.TaskQuery, task *aurora.ScheduledTask) bool {
+	if query == nil {
+		return true
+	}
+
+	assigned := task.AssignedTask
+	key := assigned.Task.Job
+	switch {
+	case query.Role != "" && query.Role != key.Role,
+		query.Environment != "" && query.Environment != key.Environment,
+		query.JobName != "" && query.JobName != key.Name,
+		len(query.Statuses) > 0 && !query.Statuses[task.Status],
+		len(query.InstanceIds) > 0 && !query.InstanceIds[assigned.InstanceId],
+		len(query.TaskIds) > 0 && !query.TaskIds[assigned.TaskId],
+		len(query.SlaveHosts) > 0 && !query.SlaveHosts[assigned.SlaveHost]:
+		return false
+	}
+
+	if len(query.JobKeys) > 0 {
+		for jobKey := range query.JobKeys {
+			if jobId(jobKey) == jobId(key) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Copy of a value sharing no memory with it, so results can be read while later calls change
+// the scheduler's state.
+func deepCopy(v interface{}) interface{} {
+	return copyValue(reflect.ValueOf(v)).Interface()
+}
+
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(copyValue(v.Elem()))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(copyValue(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, key := range v.MapKeys() {
+			c.SetMapIndex(copyValue(key), copyValue(v.MapIndex(key)))
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+func jobId(key *aurora.JobKey) string {
+	return key.Role + "/" + key.Environment + "/" + key.Name
+}
+
+func ok(result *aurora.Result_) *aurora.Response {
+	return &aurora.Response{ResponseCode: aurora.ResponseCode_OK, Result_: result}
+}
+
+func failure(code aurora.ResponseCode, message string) *aurora.Response {
+	return &aurora.Response{ResponseCode: code, Details: []*aurora.ResponseDetail{{Message: message}}}
+}
+
+func timestamp() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
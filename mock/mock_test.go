@@ -0,0 +1,285 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mock
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/rdelval/gorealis"
+	"testing"
+)
+
+func helloJob(instances int32) *realis.Job {
+	return realis.NewJob().
+		Environment("prod").
+		Role("vagrant").
+		Name("hello_world").
+		CPU(1).
+		RAM(64).
+		Disk(100).
+		InstanceCount(instances)
+}
+
+func activeCount(t *testing.T, client *Client, key *aurora.JobKey) int {
+	tasks, err := client.GetTaskStatus(&aurora.TaskQuery{
+		Role:        key.Role,
+		Environment: key.Environment,
+		JobName:     key.Name,
+		Statuses:    aurora.ACTIVE_STATES})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(tasks)
+}
+
+func TestJobOperations(t *testing.T) {
+	tests := []struct {
+		name    string
+		operate func(client *Client, key *aurora.JobKey) (*aurora.Response, error)
+		active  int
+		fails   bool
+	}{
+		{
+			name: "kill instance",
+			operate: func(client *Client, key *aurora.JobKey) (*aurora.Response, error) {
+				return client.KillInstance(key, 1)
+			},
+			active: 2,
+		},
+		{
+			name: "add instances",
+			operate: func(client *Client, key *aurora.JobKey) (*aurora.Response, error) {
+				return client.AddInstances(&aurora.InstanceKey{JobKey: key, InstanceId: 0}, 2)
+			},
+			active: 5,
+		},
+		{
+			name: "add instances from inactive instance",
+			operate: func(client *Client, key *aurora.JobKey) (*aurora.Response, error) {
+				return client.AddInstances(&aurora.InstanceKey{JobKey: key, InstanceId: 7}, 2)
+			},
+			active: 3,
+			fails:  true,
+		},
+		{
+			name: "remove instances",
+			operate: func(client *Client, key *aurora.JobKey) (*aurora.Response, error) {
+				return client.RemoveInstances(key, 2)
+			},
+			active: 1,
+		},
+		{
+			name: "remove too many instances",
+			operate: func(client *Client, key *aurora.JobKey) (*aurora.Response, error) {
+				return client.RemoveInstances(key, 4)
+			},
+			active: 3,
+			fails:  true,
+		},
+		{
+			name: "restart",
+			operate: func(client *Client, key *aurora.JobKey) (*aurora.Response, error) {
+				return client.RestartJob(key)
+			},
+			active: 3,
+		},
+		{
+			name: "kill job",
+			operate: func(client *Client, key *aurora.JobKey) (*aurora.Response, error) {
+				return client.KillJob(key)
+			},
+			active: 0,
+		},
+		{
+			name: "create twice",
+			operate: func(client *Client, key *aurora.JobKey) (*aurora.Response, error) {
+				return client.CreateJob(helloJob(1))
+			},
+			active: 3,
+			fails:  true,
+		},
+	}
+
+	for _, test := range tests {
+		client := New()
+		job := helloJob(3)
+		if _, err := client.CreateJob(job); err != nil {
+			t.Fatal(err)
+		}
+
+		response, err := test.operate(client, job.JobKey())
+		failed := err != nil || response.ResponseCode != aurora.ResponseCode_OK
+		if failed != test.fails {
+			t.Errorf("%s: response %v, err %v, want failure %v", test.name, response, err, test.fails)
+		}
+		if active := activeCount(t, client, job.JobKey()); active != test.active {
+			t.Errorf("%s: %d active tasks, want %d", test.name, active, test.active)
+		}
+	}
+}
+
+func TestResultsAreCopies(t *testing.T) {
+	client := New()
+	job := helloJob(1)
+	if _, err := client.CreateJob(job); err != nil {
+		t.Fatal(err)
+	}
+
+	job.InstanceCount(5)
+	jobs, _ := client.GetJobs("vagrant")
+	if count := jobs[0].InstanceCount; count != 1 {
+		t.Errorf("job changed by its caller has %d instances, want 1", count)
+	}
+
+	jobs[0].InstanceCount = 7
+	jobs, _ = client.GetJobs("vagrant")
+	if count := jobs[0].InstanceCount; count != 1 {
+		t.Errorf("job changed through a result has %d instances, want 1", count)
+	}
+
+	tasks, _ := client.GetTaskStatus(nil)
+	tasks[0].Status = aurora.ScheduleStatus_FAILED
+	if active := activeCount(t, client, job.JobKey()); active != 1 {
+		t.Errorf("task changed through a result left %d active tasks, want 1", active)
+	}
+}
+
+func TestUpdateTransitions(t *testing.T) {
+	pause := func(client *Client, key *aurora.JobUpdateKey) (*aurora.Response, error) {
+		return client.PauseJobUpdate(key, "pause")
+	}
+	resume := func(client *Client, key *aurora.JobUpdateKey) (*aurora.Response, error) {
+		return client.ResumeJobUpdate(key, "resume")
+	}
+	abort := func(client *Client, key *aurora.JobUpdateKey) (*aurora.Response, error) {
+		return client.AbortJobUpdate(key.Job, key.ID, "abort")
+	}
+	finish := func(status aurora.JobUpdateStatus) func(client *Client, key *aurora.JobUpdateKey) (*aurora.Response, error) {
+		return func(client *Client, key *aurora.JobUpdateKey) (*aurora.Response, error) {
+			return &aurora.Response{ResponseCode: aurora.ResponseCode_OK}, client.FinishUpdate(key, status)
+		}
+	}
+
+	type step struct {
+		action func(client *Client, key *aurora.JobUpdateKey) (*aurora.Response, error)
+		status aurora.JobUpdateStatus
+		fails  bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"pause and resume", []step{
+			{pause, aurora.JobUpdateStatus_ROLL_FORWARD_PAUSED, false},
+			{resume, aurora.JobUpdateStatus_ROLLING_FORWARD, false},
+			{finish(aurora.JobUpdateStatus_ROLLED_FORWARD), aurora.JobUpdateStatus_ROLLED_FORWARD, false},
+		}},
+		{"pause and resume a roll back", []step{
+			{finish(aurora.JobUpdateStatus_ROLLING_BACK), aurora.JobUpdateStatus_ROLLING_BACK, false},
+			{pause, aurora.JobUpdateStatus_ROLL_BACK_PAUSED, false},
+			{resume, aurora.JobUpdateStatus_ROLLING_BACK, false},
+			{finish(aurora.JobUpdateStatus_ROLLED_BACK), aurora.JobUpdateStatus_ROLLED_BACK, false},
+		}},
+		{"abort", []step{
+			{abort, aurora.JobUpdateStatus_ABORTED, false},
+			{resume, aurora.JobUpdateStatus_ABORTED, true},
+		}},
+	}
+
+	for _, test := range tests {
+		client := New()
+		client.HoldUpdates = true
+		job := helloJob(2)
+		if _, err := client.CreateJob(job); err != nil {
+			t.Fatal(err)
+		}
+
+		response, err := client.StartJobUpdate(realis.NewUpdateJob(job), "start")
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := response.Result_.StartJobUpdateResult_.Key
+
+		for i, step := range test.steps {
+			response, err := step.action(client, key)
+			failed := err != nil || response.ResponseCode != aurora.ResponseCode_OK
+			if failed != step.fails {
+				t.Errorf("%s, step %d: response %v, err %v, want failure %v", test.name, i, response, err, step.fails)
+			}
+
+			details, err := client.JobUpdateDetails(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status := details.Update.Summary.State.Status; status != step.status {
+				t.Errorf("%s, step %d: status %v, want %v", test.name, i, status, step.status)
+			}
+		}
+	}
+}
+
+func TestUpdateRollsForward(t *testing.T) {
+	client := New()
+	job := helloJob(2)
+	if _, err := client.CreateJob(job); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := client.StartJobUpdate(realis.NewUpdateJob(job).InstanceCount(4), "scale up")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	details, err := client.JobUpdateDetails(response.Result_.StartJobUpdateResult_.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress := realis.UpdateProgress(details); progress != 100 {
+		t.Errorf("progress = %v, want 100", progress)
+	}
+	if active := activeCount(t, client, job.JobKey()); active != 4 {
+		t.Errorf("%d active tasks, want 4", active)
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	client := New()
+	job := helloJob(2)
+	if _, err := client.CreateJob(job); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.DrainHosts(); err == nil {
+		t.Error("drained no hosts without an error")
+	}
+
+	if _, err := client.DrainHosts(Host); err != nil {
+		t.Fatal(err)
+	}
+	if active := activeCount(t, client, job.JobKey()); active != 0 {
+		t.Errorf("%d active tasks on a drained host, want 0", active)
+	}
+	statuses, _ := client.MaintenanceStatus(Host)
+	if mode := statuses[Host]; mode != aurora.MaintenanceMode_DRAINED {
+		t.Errorf("host in %v, want DRAINED", mode)
+	}
+
+	if _, err := client.EndMaintenance(Host); err != nil {
+		t.Fatal(err)
+	}
+	statuses, _ = client.MaintenanceStatus(Host)
+	if mode := statuses[Host]; mode != aurora.MaintenanceMode_NONE {
+		t.Errorf("host in %v after ending maintenance, want NONE", mode)
+	}
+}
@@ -0,0 +1,396 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"sort"
+	"sync"
+	"time"
+)
+
+type MonitorKind int
+
+const (
+	// Waits for an update to reach a terminal state.
+	UpdateMonitor MonitorKind = iota
+
+	// Waits for a number of instances of a job to be running.
+	InstanceMonitor
+
+	// Waits for hosts to reach a maintenance mode.
+	HostMonitor
+
+	// Follows the tasks matching a query until removed, reporting only changes.
+	TaskMonitor
+)
+
+// Change observed by a monitor of a MonitorSet. The last event of a monitor has Done set, after
+// which the monitor is removed from the set.
+type MonitorEvent struct {
+	ID   string
+	Kind MonitorKind
+	Time time.Time
+	Done bool
+	Err  error
+
+	// Set for update monitors.
+	UpdateStatus aurora.JobUpdateStatus
+
+	// Set for instance monitors.
+	RunningInstances int
+
+	// Set for host monitors.
+	HostModes map[string]aurora.MaintenanceMode
+
+	// Set for task monitors: digest of the matching tasks and what changed since the last
+	// event. The first event lists every matching task as added.
+	Fingerprint string
+	Delta       *TaskDelta
+}
+
+type monitor struct {
+	id   string
+	kind MonitorKind
+
+	updateKey *aurora.JobUpdateKey
+
+	jobKey    *aurora.JobKey
+	instances int
+
+	hosts []string
+	mode  aurora.MaintenanceMode
+
+	// Guards tasks and last, Poll may be called from several goroutines.
+	mu sync.Mutex
+
+	query *aurora.TaskQuery
+	tasks []*aurora.ScheduledTask
+
+	// Last event sent, to only report changes.
+	last *MonitorEvent
+}
+
+// Query shared by every monitor that needs it in a polling round.
+func (m *monitor) queryKey() string {
+	switch m.kind {
+	case UpdateMonitor:
+		return "update:" + m.updateKey.ID
+	case InstanceMonitor:
+		return "job:" + m.jobKey.Role + "/" + m.jobKey.Environment + "/" + m.jobKey.Name
+	case TaskMonitor:
+		return "tasks:" + taskQueryKey(m.query)
+	default:
+		// All hosts are fetched with a single call.
+		return "hosts"
+	}
+}
+
+// Tracks many updates, jobs and hosts at once for controllers following dozens of jobs.
+// Monitors needing the same query share it, at most a fixed number of queries are sent per
+// polling round, and every change is reported on a single event stream.
+type MonitorSet struct {
+	client  Realis
+	polling PollingPolicy
+	budget  int
+
+	events *eventStream[MonitorEvent]
+
+	mu       sync.Mutex
+	monitors map[string]*monitor
+	nextId   int
+	cursor   int
+
+	// Batches of each update already reported to the client's update metrics hook.
+	batches map[string]int
+}
+
+// Create a monitor set polling every interval and sending at most maxQueries queries per
+// round, unlimited when 0. Queries left out of a round are sent first in the next one.
+func NewMonitorSet(client Realis, interval time.Duration, maxQueries int) *MonitorSet {
+	return &MonitorSet{
+		client:   client,
+		polling:  PollingPolicy{Min: interval, Max: interval},
+		budget:   maxQueries,
+		events:   newEventStream[MonitorEvent](eventBufferSize, Block),
+		monitors: make(map[string]*monitor),
+		batches:  make(map[string]int)}
+}
+
+// Poll adaptively instead of at the fixed interval the set was created with: fast while
+// monitors observe changes, slower while everything is stable.
+func (s *MonitorSet) SetPollingPolicy(policy PollingPolicy) {
+	s.polling = policy
+}
+
+// Stream of changes observed by all monitors of the set. By default it must be consumed for
+// polling to make progress, see SetEventBuffer.
+func (s *MonitorSet) Events() <-chan MonitorEvent {
+	return s.events.ch
+}
+
+// Replace the event stream with one buffering size events and applying the given policy when
+// full. Dropping policies may drop the final event of a monitor. Must be called before Events
+// and Run.
+func (s *MonitorSet) SetEventBuffer(size int, policy OverflowPolicy) {
+	s.events = newEventStream[MonitorEvent](size, policy)
+}
+
+// Number of events discarded because the buffer was full.
+func (s *MonitorSet) DroppedEvents() uint64 {
+	return s.events.droppedEvents()
+}
+
+// Why the event stream was closed, nil while it is open. Polling stops once it is closed.
+func (s *MonitorSet) EventsErr() error {
+	return s.events.closeErr()
+}
+
+// Watch an update until it reaches a terminal state. Returns the monitor id.
+func (s *MonitorSet) WatchUpdate(key *aurora.JobUpdateKey) string {
+	return s.add(&monitor{kind: UpdateMonitor, updateKey: key})
+}
+
+// Watch a job until at least count of its instances are running. Returns the monitor id.
+func (s *MonitorSet) WatchInstances(key *aurora.JobKey, count int) string {
+	return s.add(&monitor{kind: InstanceMonitor, jobKey: key, instances: count})
+}
+
+// Watch hosts until all of them are in the given maintenance mode. Returns the monitor id.
+func (s *MonitorSet) WatchHosts(mode aurora.MaintenanceMode, hosts ...string) string {
+	return s.add(&monitor{kind: HostMonitor, hosts: hosts, mode: mode})
+}
+
+// Follow the tasks matching a query, reporting tasks added, removed or changing status. The
+// monitor runs until removed. Returns the monitor id.
+func (s *MonitorSet) WatchTasks(query *aurora.TaskQuery) string {
+	return s.add(&monitor{kind: TaskMonitor, query: query})
+}
+
+// Stop a monitor without a final event.
+func (s *MonitorSet) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.monitors, id)
+}
+
+// Number of monitors still running.
+func (s *MonitorSet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.monitors)
+}
+
+func (s *MonitorSet) add(m *monitor) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextId++
+	m.id = fmt.Sprintf("monitor-%d", s.nextId)
+	s.monitors[m.id] = m
+	return m.id
+}
+
+// Poll according to the polling policy until stop is closed.
+func (s *MonitorSet) Run(stop <-chan struct{}) {
+	poll := newPoller(s.polling)
+	rounds := 0
+
+	for {
+		// Rounds that report something count as a change of state.
+		if s.Poll() > 0 {
+			rounds++
+		}
+		if s.EventsErr() != nil {
+			return
+		}
+		select {
+		case <-stop:
+			return
+		case <-clockOf(s.client).After(poll.next(rounds)):
+		}
+	}
+}
+
+// Run a single polling round. Returns the number of events sent.
+func (s *MonitorSet) Poll() int {
+	groups, order := s.groups()
+	sent := 0
+
+	for _, queryKey := range order {
+		group := groups[queryKey]
+		now := clockOf(s.client).Now()
+
+		switch group[0].kind {
+		case UpdateMonitor:
+			status, err := s.updateStatus(group[0].updateKey)
+			for _, m := range group {
+				sent += s.report(m, MonitorEvent{Time: now, Err: err, UpdateStatus: status,
+					Done: err == nil && IsUpdateTerminal(status)})
+			}
+
+		case InstanceMonitor:
+			running, err := runningTaskIds(s.client, group[0].jobKey)
+			for _, m := range group {
+				sent += s.report(m, MonitorEvent{Time: now, Err: err, RunningInstances: len(running),
+					Done: err == nil && len(running) >= m.instances})
+			}
+
+		case HostMonitor:
+			var hosts []string
+			for _, m := range group {
+				hosts = append(hosts, m.hosts...)
+			}
+
+			modes, err := s.client.MaintenanceStatus(hosts...)
+			for _, m := range group {
+				event := MonitorEvent{Time: now, Err: err, HostModes: make(map[string]aurora.MaintenanceMode)}
+				done := err == nil
+				for _, host := range m.hosts {
+					event.HostModes[host] = modes[host]
+					done = done && modes[host] == m.mode
+				}
+				event.Done = done
+				sent += s.report(m, event)
+			}
+
+		case TaskMonitor:
+			tasks, err := s.client.GetTasksWithoutConfigs(group[0].query)
+			fingerprint := TaskFingerprint(tasks)
+			for _, m := range group {
+				event := MonitorEvent{Time: now, Err: err}
+				m.mu.Lock()
+				if err == nil {
+					event.Fingerprint = fingerprint
+					if m.last == nil || m.last.Fingerprint != fingerprint {
+						delta := DiffTasks(m.tasks, tasks)
+						event.Delta = &delta
+					}
+				}
+				if event.Delta != nil {
+					m.tasks = tasks
+				}
+				m.mu.Unlock()
+				sent += s.report(m, event)
+			}
+		}
+	}
+
+	return sent
+}
+
+// Status of an update. With an update metrics hook set on the client, the details of the update
+// are fetched instead to report the batches that finished since the last round. Clients other
+// than RealisClient only provide the details.
+func (s *MonitorSet) updateStatus(key *aurora.JobUpdateKey) (aurora.JobUpdateStatus, error) {
+	var hook UpdateMetricsHook
+	if r, ok := s.client.(*RealisClient); ok {
+		if hook = r.metricsHook; hook == nil {
+			return r.jobUpdateStatus(key)
+		}
+	}
+
+	details, err := s.client.JobUpdateDetails(key)
+	if err != nil {
+		return 0, err
+	}
+	status := details.Update.Summary.State.Status
+	if hook == nil {
+		return status, nil
+	}
+
+	s.mu.Lock()
+	metrics := updateBatchMetrics(details, s.batches[key.ID])
+	s.batches[key.ID] += len(metrics)
+	if IsUpdateTerminal(status) {
+		delete(s.batches, key.ID)
+	}
+	s.mu.Unlock()
+
+	for _, batch := range metrics {
+		hook(batch)
+	}
+	return status, nil
+}
+
+// Monitors grouped by shared query, and the queries to send this round within the budget.
+func (s *MonitorSet) groups() (map[string][]*monitor, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups := make(map[string][]*monitor)
+	for _, m := range s.monitors {
+		groups[m.queryKey()] = append(groups[m.queryKey()], m)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if s.budget <= 0 || len(keys) <= s.budget {
+		return groups, keys
+	}
+
+	// Rotate through the queries so every monitor is served within a few rounds.
+	order := make([]string, 0, s.budget)
+	for i := 0; i < s.budget; i++ {
+		order = append(order, keys[(s.cursor+i)%len(keys)])
+	}
+	s.cursor = (s.cursor + s.budget) % len(keys)
+
+	return groups, order
+}
+
+// Send an event if it differs from the last one of the monitor, removing finished monitors.
+// Returns the number of events sent.
+func (s *MonitorSet) report(m *monitor, event MonitorEvent) int {
+	event.ID = m.id
+	event.Kind = m.kind
+
+	m.mu.Lock()
+	if m.last != nil && sameObservation(*m.last, event) {
+		m.mu.Unlock()
+		return 0
+	}
+	m.last = &event
+	m.mu.Unlock()
+
+	if event.Done {
+		s.Remove(m.id)
+	}
+
+	if !s.events.publish(event) {
+		return 0
+	}
+	return 1
+}
+
+func sameObservation(a, b MonitorEvent) bool {
+	if a.Done != b.Done || (a.Err == nil) != (b.Err == nil) ||
+		a.UpdateStatus != b.UpdateStatus || a.RunningInstances != b.RunningInstances ||
+		a.Fingerprint != b.Fingerprint || len(a.HostModes) != len(b.HostModes) {
+		return false
+	}
+
+	for host, mode := range a.HostModes {
+		if b.HostModes[host] != mode {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"net/http"
+)
+
+// Authenticate every request with a bearer token obtained from the token source, allowing
+// access to clusters fronted by OIDC proxies. Tokens are cached and refreshed once expired.
+func WithBearerToken(config *RealisConfig, tokenSource oauth2.TokenSource) error {
+	if config.httpClient == nil {
+		return errors.New("Bearer tokens require an HTTP transport")
+	}
+
+	next := config.httpClient.Transport
+	if next == nil {
+		base, err := baseTransport(config)
+		if err != nil {
+			return err
+		}
+		next = base
+	}
+
+	config.httpClient.Transport = &bearerTransport{
+		next:        next,
+		tokenSource: oauth2.ReuseTokenSource(nil, tokenSource)}
+
+	return nil
+}
+
+type bearerTransport struct {
+	next        http.RoundTripper
+	tokenSource oauth2.TokenSource
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.tokenSource.Token()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error obtaining bearer token")
+	}
+
+	// A RoundTripper must not modify the original request.
+	authReq := new(http.Request)
+	*authReq = *req
+	authReq.Header = req.Header.Clone()
+	token.SetAuthHeader(authReq)
+
+	return t.next.RoundTrip(authReq)
+}
@@ -0,0 +1,209 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/pkg/errors"
+	"net/http"
+	"time"
+)
+
+// Option of NewRealisClient.
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	url          string
+	apiPath      string
+	endpoints    []string
+	transport    thrift.TTransport
+	roundTripper http.RoundTripper
+	username     string
+	password     string
+	timeout      time.Duration
+	retries      *RetryPolicy
+	polling      *PollingPolicy
+	verify       time.Duration
+	logger       Logger
+	connections  int
+	check        bool
+	debugRPCs    bool
+	configFuncs  []func(*RealisConfig) error
+}
+
+// Scheduler to talk to, given as host, host:port or a full URL.
+func WithURL(url string) ClientOption {
+	return func(o *clientOptions) { o.url = url }
+}
+
+// Path the scheduler serves its API on, /api by default.
+func WithAPIPath(apiPath string) ClientOption {
+	return func(o *clientOptions) { o.apiPath = apiPath }
+}
+
+// Spread calls over several schedulers, see NewConfigWithEndpoints.
+func WithEndpoints(urls ...string) ClientOption {
+	return func(o *clientOptions) { o.endpoints = urls }
+}
+
+// Talk to the scheduler through an arbitrary thrift transport, e.g. a test double.
+func WithTransport(transport thrift.TTransport) ClientOption {
+	return func(o *clientOptions) { o.transport = transport }
+}
+
+// Send HTTP requests through the given RoundTripper.
+func WithRoundTripper(roundTripper http.RoundTripper) ClientOption {
+	return func(o *clientOptions) { o.roundTripper = roundTripper }
+}
+
+// Authenticate with basic authorization.
+func WithBasicAuth(username string, password string) ClientOption {
+	return func(o *clientOptions) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// Time allowed for each HTTP request, 10 seconds by default.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) { o.timeout = timeout }
+}
+
+// Retry transient failures, see SetRetryPolicy.
+func WithRetries(policy RetryPolicy) ClientOption {
+	return func(o *clientOptions) { o.retries = &policy }
+}
+
+// Poll the scheduler according to the policy, see SetPollingPolicy.
+func WithPolling(policy PollingPolicy) ClientOption {
+	return func(o *clientOptions) { o.polling = &policy }
+}
+
+// Verify mutations took effect, see SetVerifyMutations.
+func WithVerifyMutations(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) { o.verify = timeout }
+}
+
+// Log what the client does, see SetLogger.
+func WithLogger(logger Logger, debugRPCs bool) ClientOption {
+	return func(o *clientOptions) {
+		o.logger = logger
+		o.debugRPCs = debugRPCs
+	}
+}
+
+// Let up to n calls be made concurrently, see SetMaxConnections.
+func WithMaxConnections(n int) ClientOption {
+	return func(o *clientOptions) { o.connections = n }
+}
+
+// Fail calls answered with a response that is not OK, see SetCheckResponses.
+func WithCheckResponses() ClientOption {
+	return func(o *clientOptions) { o.check = true }
+}
+
+// Apply one of the configuration helpers, e.g.
+//
+//	WithConfig(func(c *RealisConfig) error {
+//		return WithThriftLimits(c, ThriftLimits{MaxContainerSize: 100000})
+//	})
+func WithConfig(apply func(config *RealisConfig) error) ClientOption {
+	return func(o *clientOptions) { o.configFuncs = append(o.configFuncs, apply) }
+}
+
+// Create a client from options, e.g.
+//
+//	r, err := realis.NewRealisClient(
+//		realis.WithURL("http://192.168.33.7:8081"),
+//		realis.WithBasicAuth("aurora", "secret"),
+//		realis.WithTimeout(30*time.Second))
+//
+// Preferred over building a RealisConfig and passing it to NewClient.
+func NewRealisClient(options ...ClientOption) (*RealisClient, error) {
+	o := &clientOptions{apiPath: defaultAPIPath}
+	for _, option := range options {
+		option(o)
+	}
+
+	config, err := o.config()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.retries != nil {
+		r.SetRetryPolicy(*o.retries)
+	}
+	if o.polling != nil {
+		r.SetPollingPolicy(*o.polling)
+	}
+	r.SetVerifyMutations(o.verify)
+	r.SetLogger(o.logger, o.debugRPCs)
+	r.SetCheckResponses(o.check)
+	if o.connections != 0 {
+		if err := r.SetMaxConnections(o.connections); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (o *clientOptions) config() (RealisConfig, error) {
+	var config RealisConfig
+	var err error
+
+	switch {
+	case o.transport != nil:
+		config = NewConfigWithTransport(o.transport)
+	case len(o.endpoints) > 0:
+		config, err = NewConfigWithEndpointsAndAPIPath(o.endpoints, o.apiPath)
+	case o.url != "":
+		var apiPath string
+		if apiPath, err = normalizeAPIPath(o.apiPath); err == nil {
+			config, err = newHTTPConfig(o.url, apiPath, o.roundTripper)
+		}
+	default:
+		err = errors.New("No scheduler given, use WithURL, WithEndpoints or WithTransport")
+	}
+	if err != nil {
+		return RealisConfig{}, err
+	}
+
+	if o.timeout > 0 {
+		if config.httpClient == nil {
+			return RealisConfig{}, errors.New("Timeouts require an HTTP transport")
+		}
+		config.httpClient.Timeout = o.timeout
+	}
+
+	if o.username != "" || o.password != "" {
+		if err := AddBasicAuth(&config, o.username, o.password); err != nil {
+			return RealisConfig{}, err
+		}
+	}
+
+	for _, apply := range o.configFuncs {
+		if err := apply(&config); err != nil {
+			return RealisConfig{}, err
+		}
+	}
+
+	return config, nil
+}
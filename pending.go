@@ -0,0 +1,222 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Number of pending tasks vetoed for a given reason.
+type ReasonCount struct {
+	Reason string
+	Tasks  int
+}
+
+// Explanation of why a job's tasks are not being scheduled.
+type PendingDiagnosis struct {
+	JobKey *aurora.JobKey
+
+	// Tasks that have been PENDING for longer than the threshold.
+	PendingTasks int
+	LongestWait  time.Duration
+
+	// Reasons the scheduler gave for not placing the tasks, most common first. Reasons such as
+	// insufficient resources or unsatisfied constraints are counted separately.
+	Reasons []ReasonCount
+}
+
+// Pending task along with the time it became pending and the reasons it is not scheduled.
+type pendingTask struct {
+	task    *aurora.ScheduledTask
+	since   time.Time
+	reasons []string
+}
+
+// Correlate the tasks of a job that have been PENDING for longer than minWait with the
+// scheduler's pending reasons, aggregated by resource shortfall or unsatisfied constraint.
+func (r *RealisClient) DiagnosePending(key *aurora.JobKey, minWait time.Duration) (*PendingDiagnosis, error) {
+	tasks, err := r.pendingTasks(&aurora.TaskQuery{
+		Role:        key.Role,
+		Environment: key.Environment,
+		JobName:     key.Name}, minWait)
+	if err != nil {
+		return nil, err
+	}
+
+	return diagnose(key, tasks, r.clock.Now()), nil
+}
+
+func diagnose(key *aurora.JobKey, tasks []pendingTask, now time.Time) *PendingDiagnosis {
+	diagnosis := &PendingDiagnosis{JobKey: key, PendingTasks: len(tasks)}
+
+	counts := make(map[string]int)
+	for _, task := range tasks {
+		if wait := now.Sub(task.since); wait > diagnosis.LongestWait {
+			diagnosis.LongestWait = wait
+		}
+		for _, reason := range task.reasons {
+			counts[reason]++
+		}
+	}
+
+	for reason, count := range counts {
+		diagnosis.Reasons = append(diagnosis.Reasons, ReasonCount{Reason: reason, Tasks: count})
+	}
+	sort.Slice(diagnosis.Reasons, func(i, j int) bool {
+		if diagnosis.Reasons[i].Tasks != diagnosis.Reasons[j].Tasks {
+			return diagnosis.Reasons[i].Tasks > diagnosis.Reasons[j].Tasks
+		}
+		return diagnosis.Reasons[i].Reason < diagnosis.Reasons[j].Reason
+	})
+
+	return diagnosis
+}
+
+// Retrieve the tasks selected by the role, environment and job name of the query that have been
+// pending for at least minWait along with the reasons the scheduler gives for not placing them.
+func (r *RealisClient) pendingTasks(query *aurora.TaskQuery, minWait time.Duration) ([]pendingTask, error) {
+	tasks, err := r.GetTasksWithoutConfigs(&aurora.TaskQuery{
+		Role:        query.Role,
+		Environment: query.Environment,
+		JobName:     query.JobName,
+		Statuses:    map[aurora.ScheduleStatus]bool{aurora.ScheduleStatus_PENDING: true}})
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to retrieve pending tasks")
+	}
+
+	now := r.clock.Now()
+	var pending []pendingTask
+	for _, task := range tasks {
+		if task.Status != aurora.ScheduleStatus_PENDING || len(task.TaskEvents) == 0 {
+			continue
+		}
+
+		since := msToTime(task.TaskEvents[len(task.TaskEvents)-1].Timestamp)
+		if now.Sub(since) >= minWait {
+			pending = append(pending, pendingTask{task: task, since: since})
+		}
+	}
+
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	// The scheduler rejects pending reason queries that set anything besides the role,
+	// environment and job name.
+	reasonsQuery := &aurora.TaskQuery{Role: query.Role, Environment: query.Environment, JobName: query.JobName}
+	response, err := r.thriftCall("GetPendingReason", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetPendingReason(reasonsQuery)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for pending reasons")
+	}
+	if response.ResponseCode != aurora.ResponseCode_OK {
+		return nil, errors.Errorf("Pending reasons rejected by Aurora Scheduler: %s", responseMessage(response))
+	}
+
+	reasons := make(map[string][]string)
+	if response.Result_ != nil && response.Result_.GetPendingReasonResult_ != nil {
+		for reason := range response.Result_.GetPendingReasonResult_.Reasons {
+			reasons[reason.TaskId] = splitPendingReason(reason.Reason)
+		}
+	}
+
+	for i := range pending {
+		pending[i].reasons = reasons[pending[i].task.AssignedTask.TaskId]
+	}
+
+	return pending, nil
+}
+
+// The scheduler joins the vetoes of a task into a single string, split them so they can be
+// aggregated individually.
+func splitPendingReason(reason string) []string {
+	var parts []string
+	for _, part := range strings.FieldsFunc(reason, func(c rune) bool { return c == ';' || c == ',' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// Pending task as listed in a triage report.
+type PendingTaskInfo struct {
+	TaskId     string
+	InstanceId int32
+	Wait       time.Duration
+	Reasons    []string
+}
+
+// Pending tasks of a single job, longest waiting first.
+type PendingJobReport struct {
+	JobKey      *aurora.JobKey
+	LongestWait time.Duration
+	Tasks       []PendingTaskInfo
+}
+
+// List every task of a role pending for at least minWait, with its pending reasons, grouped by
+// job. Jobs are ordered by the wait time of their longest pending task so operators triaging a
+// scheduling backlog see the worst cases first.
+func (r *RealisClient) PendingTriage(role string, minWait time.Duration) ([]PendingJobReport, error) {
+	tasks, err := r.pendingTasks(&aurora.TaskQuery{Role: role}, minWait)
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.clock.Now()
+	jobs := make(map[string]*PendingJobReport)
+	var reports []*PendingJobReport
+
+	for _, pending := range tasks {
+		assigned := pending.task.AssignedTask
+		if assigned.Task == nil || assigned.Task.Job == nil {
+			continue
+		}
+
+		key := assigned.Task.Job
+		id := key.Role + "/" + key.Environment + "/" + key.Name
+		report, ok := jobs[id]
+		if !ok {
+			report = &PendingJobReport{JobKey: key}
+			jobs[id] = report
+			reports = append(reports, report)
+		}
+
+		wait := now.Sub(pending.since)
+		if wait > report.LongestWait {
+			report.LongestWait = wait
+		}
+
+		report.Tasks = append(report.Tasks, PendingTaskInfo{
+			TaskId:     assigned.TaskId,
+			InstanceId: assigned.InstanceId,
+			Wait:       wait,
+			Reasons:    pending.reasons})
+	}
+
+	sorted := make([]PendingJobReport, 0, len(reports))
+	for _, report := range reports {
+		sort.Slice(report.Tasks, func(i, j int) bool { return report.Tasks[i].Wait > report.Tasks[j].Wait })
+		sorted = append(sorted, *report)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].LongestWait > sorted[j].LongestWait })
+
+	return sorted, nil
+}
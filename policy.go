@@ -0,0 +1,119 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"time"
+)
+
+// Operation about to be sent to the scheduler, as seen by policies. Policies may rewrite
+// the operation by modifying the job key or the job configuration in place.
+type Operation struct {
+	Name   string
+	JobKey *aurora.JobKey
+
+	// Configuration sent along with the operation, only set for CreateJob and StartJobUpdate.
+	Job *Job
+
+	// Set when the caller asked to bypass deploy windows for this operation.
+	Override bool
+
+	// Hosts affected by host maintenance operations, which have no job key.
+	Hosts []string
+
+	// When the operation is authorized, read from the client's clock.
+	Time time.Time
+}
+
+// Hook consulted before every mutating operation. Returning an error rejects the operation.
+type Policy func(op *Operation) error
+
+// Returned when a policy rejects an operation.
+type PolicyError struct {
+	Operation string
+	JobKey    *aurora.JobKey
+	Err       error
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s on %s/%s/%s rejected by policy: %v",
+		e.Operation, e.JobKey.Role, e.JobKey.Environment, e.JobKey.Name, e.Err)
+}
+
+func (e *PolicyError) Cause() error {
+	return e.Err
+}
+
+// Register a policy consulted before every mutating operation. Policies run in the order
+// they were added and should be registered before the client is shared between goroutines.
+func (r *RealisClient) AddPolicy(policy Policy) {
+	r.policies = append(r.policies, policy)
+}
+
+// Policy rejecting operations on jobs outside the given environments, e.g. to keep a staging
+// client from touching prod.
+func EnvironmentPolicy(environments ...string) Policy {
+	allowed := make(map[string]bool)
+	for _, env := range environments {
+		allowed[env] = true
+	}
+
+	return func(op *Operation) error {
+		if !allowed[op.JobKey.Environment] {
+			return fmt.Errorf("environment %q is not allowed for this client", op.JobKey.Environment)
+		}
+		return nil
+	}
+}
+
+// Policy rejecting operations on jobs owned by roles other than the given ones.
+func RolePolicy(roles ...string) Policy {
+	allowed := make(map[string]bool)
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(op *Operation) error {
+		if !allowed[op.JobKey.Role] {
+			return fmt.Errorf("role %q is not allowed for this client", op.JobKey.Role)
+		}
+		return nil
+	}
+}
+
+// Run an operation through the registered policies, the freeze calendar and, for destructive
+// operations, the approver.
+func (r *RealisClient) authorize(op *Operation) error {
+	op.Time = r.clock.Now()
+	for _, policy := range r.policies {
+		if err := policy(op); err != nil {
+			return &PolicyError{Operation: op.Name, JobKey: op.JobKey, Err: err}
+		}
+	}
+
+	if err := r.checkFreeze(op); err != nil {
+		return err
+	}
+
+	if r.approver != nil && destructiveOperations[op.Name] {
+		if err := r.approver(op); err != nil {
+			return &ApprovalError{Operation: op.Name, JobKey: op.JobKey, Err: err}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"math/rand"
+	"time"
+)
+
+// How often functions waiting on the scheduler poll it. Polling starts at Min and the interval
+// grows by Backoff after every poll that observed no change, up to Max, going back to Min as
+// soon as something changes. Jitter spreads the polls of many watchers over time.
+type PollingPolicy struct {
+	Min time.Duration
+	Max time.Duration
+
+	// Growth of the interval while nothing changes, e.g. 1.5. Values below 1 keep it constant.
+	Backoff float64
+
+	// Fraction of the interval randomly added or removed, e.g. 0.1 for +/-10%.
+	Jitter float64
+}
+
+// Fixed interval polling, the behavior when no policy is set.
+var DefaultPollingPolicy = PollingPolicy{Min: defaultPollInterval, Max: defaultPollInterval, Backoff: 1}
+
+// Set the polling policy used by the functions waiting on the scheduler, e.g. AwaitJobUpdate.
+func (r *RealisClient) SetPollingPolicy(policy PollingPolicy) {
+	r.polling = policy
+}
+
+func (r *RealisClient) newPoller() *poller {
+	return newPoller(r.polling)
+}
+
+// Computes the wait between consecutive polls according to a policy.
+type poller struct {
+	policy   PollingPolicy
+	interval time.Duration
+	state    interface{}
+	polled   bool
+}
+
+func newPoller(policy PollingPolicy) *poller {
+	if policy.Min <= 0 {
+		policy = DefaultPollingPolicy
+	}
+	if policy.Max < policy.Min {
+		policy.Max = policy.Min
+	}
+	return &poller{policy: policy, interval: policy.Min}
+}
+
+// Record the state observed by the last poll and return how long to wait before the next one.
+// The state must be comparable with ==.
+func (p *poller) next(state interface{}) time.Duration {
+	if p.polled && state == p.state && p.policy.Backoff > 1 {
+		p.interval = time.Duration(float64(p.interval) * p.policy.Backoff)
+		if p.interval > p.policy.Max {
+			p.interval = p.policy.Max
+		}
+	} else if !p.polled || state != p.state {
+		p.interval = p.policy.Min
+	}
+	p.state = state
+	p.polled = true
+
+	wait := p.interval
+	if p.policy.Jitter > 0 {
+		wait += time.Duration((rand.Float64()*2 - 1) * p.policy.Jitter * float64(p.interval))
+	}
+	return wait
+}
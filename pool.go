@@ -0,0 +1,55 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"bytes"
+	"encoding/json"
+	"gen-go/apache/aurora"
+	"io"
+	"sync"
+)
+
+// Buffers grown beyond this size are left to the garbage collector rather than kept around.
+const maxPooledBuffer = 1 << 20
+
+// Buffers reused to read the JSON bodies polled from schedulers, agents and observers.
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// Decode a JSON body through a pooled buffer, saving an allocation per poll in monitor heavy
+// services.
+func decodeJSON(body io.Reader, v interface{}) error {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBuffer {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	if _, err := buf.ReadFrom(body); err != nil {
+		return err
+	}
+	return json.Unmarshal(buf.Bytes(), v)
+}
+
+// Status set of the queries for running tasks. Built per query since callers and the thrift
+// code may modify the query. Thrift requests and responses are allocated by the generated code
+// on every call and are not pooled, only the JSON bodies above are.
+func runningStates() map[aurora.ScheduleStatus]bool {
+	return map[aurora.ScheduleStatus]bool{aurora.ScheduleStatus_RUNNING: true}
+}
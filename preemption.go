@@ -0,0 +1,191 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+)
+
+// Priority of the task relative to other non-production tasks of the same role. Higher
+// priority tasks may preempt lower priority ones.
+func (a *Job) Priority(priority int32) *Job {
+	a.jobConfig.TaskConfig.Priority = priority
+	return a
+}
+
+// Mark the job as production. Deprecated by Aurora in favor of tiers but still honored when
+// no tier is set.
+func (a *Job) Production(production bool) *Job {
+	a.jobConfig.TaskConfig.Production = &production
+	return a
+}
+
+// Name of the tier the job runs in, e.g. preferred, preemptible or revocable.
+func (a *Job) Tier(tier string) *Job {
+	a.jobConfig.TaskConfig.Tier = &tier
+	return a
+}
+
+// Run the job on revocable (oversubscribed) cpu and ram, or explicitly not. Revocable resources
+// are granted through tiers, so the tier is picked or checked by ResolveTier.
+func (a *Job) Revocable(revocable bool) *Job {
+	a.revocable = &revocable
+	return a
+}
+
+// Check the job's tier against the cluster's tier configuration, as returned by
+// GetTierConfigs. When the job asked for revocable resources without naming a tier, a
+// revocable tier is picked for it.
+func (a *Job) ResolveTier(tiers *aurora.GetTierConfigResult_) error {
+	settings := make(map[string]map[string]string)
+	for tier := range tiers.Tiers {
+		settings[tier.Name] = tier.Settings
+	}
+
+	taskConfig := a.jobConfig.TaskConfig
+	if taskConfig.Tier == nil {
+		if a.revocable == nil || !*a.revocable {
+			return nil
+		}
+
+		tier, ok := revocableTier(settings)
+		if !ok {
+			return errors.New("No revocable tier is configured in the cluster")
+		}
+		a.Tier(tier)
+		return nil
+	}
+
+	tier := *taskConfig.Tier
+	tierSettings, ok := settings[tier]
+	if !ok {
+		return errors.Errorf("Tier %q is not configured in the cluster", tier)
+	}
+
+	revocable := tierSettings["revocable"] == "true"
+	if a.revocable != nil && *a.revocable != revocable {
+		return errors.Errorf("Tier %q has revocable=%t but the job asked for revocable=%t",
+			tier, revocable, *a.revocable)
+	}
+
+	return nil
+}
+
+// Fetch the cluster's tiers and resolve the job's tier against them.
+func (r *RealisClient) ResolveTier(job *Job) error {
+	tiers, err := r.GetTierConfigs()
+	if err != nil {
+		return err
+	}
+	return job.ResolveTier(tiers)
+}
+
+// Name of a revocable tier, preferring the one named revocable.
+func revocableTier(settings map[string]map[string]string) (string, bool) {
+	if settings["revocable"]["revocable"] == "true" {
+		return "revocable", true
+	}
+
+	var names []string
+	for name, tierSettings := range settings {
+		if tierSettings["revocable"] == "true" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return "", false
+	}
+
+	sort.Strings(names)
+	return names[0], true
+}
+
+// Effective preemption behavior of a job configuration.
+type Preemption struct {
+	Tier        string
+	Preemptible bool
+	Revocable   bool
+
+	// Settings that are ignored or contradict each other in the configuration.
+	Warnings []string
+}
+
+func (p Preemption) String() string {
+	explanation := fmt.Sprintf("tier %q: preemptible=%t revocable=%t", p.Tier, p.Preemptible, p.Revocable)
+	if len(p.Warnings) > 0 {
+		explanation += "; " + strings.Join(p.Warnings, "; ")
+	}
+	return explanation
+}
+
+// Settings of the tiers shipped with Aurora, used when the cluster's tiers are not provided.
+var defaultTiers = map[string]map[string]string{
+	"preferred":   {"preemptible": "false", "revocable": "false"},
+	"preemptible": {"preemptible": "true", "revocable": "false"},
+	"revocable":   {"preemptible": "true", "revocable": "true"},
+}
+
+// Explain whether the job can be preempted given its priority, production flag and tier, as
+// these fields interact in non obvious ways. Tiers may come from GetTierConfigs, Aurora's
+// default tiers are assumed when nil.
+func (a *Job) Preemption(tiers *aurora.GetTierConfigResult_) Preemption {
+	taskConfig := a.jobConfig.TaskConfig
+	production := taskConfig.Production != nil && *taskConfig.Production
+
+	settings := defaultTiers
+	defaultTier := "preemptible"
+	if tiers != nil {
+		settings = make(map[string]map[string]string)
+		for tier := range tiers.Tiers {
+			settings[tier.Name] = tier.Settings
+		}
+		defaultTier = tiers.DefaultTierName
+	}
+
+	var preemption Preemption
+	switch {
+	case taskConfig.Tier != nil:
+		preemption.Tier = *taskConfig.Tier
+	case production:
+		preemption.Tier = "preferred"
+	default:
+		preemption.Tier = defaultTier
+	}
+
+	tierSettings, ok := settings[preemption.Tier]
+	if !ok {
+		preemption.Warnings = append(preemption.Warnings,
+			fmt.Sprintf("tier %q is not configured in the cluster", preemption.Tier))
+	}
+	preemption.Preemptible = tierSettings["preemptible"] == "true"
+	preemption.Revocable = tierSettings["revocable"] == "true"
+
+	if taskConfig.Tier != nil && taskConfig.Production != nil && production == preemption.Preemptible {
+		preemption.Warnings = append(preemption.Warnings,
+			fmt.Sprintf("production=%t contradicts tier %q", production, preemption.Tier))
+	}
+
+	if taskConfig.Priority != 0 && !preemption.Preemptible {
+		preemption.Warnings = append(preemption.Warnings,
+			"priority only affects preemption between preemptible tasks of the same role")
+	}
+
+	return preemption
+}
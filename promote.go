@@ -0,0 +1,104 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+)
+
+// Copy the configuration of a job from one environment of its role to another, e.g. devel to
+// staging, for staged promotion pipelines. The key's environment is ignored. overrides, when
+// not nil, may adjust the promoted job, e.g. its instance count, before it is sent. Jobs
+// already present in the target environment are updated, others are created. Update settings
+// stored in the source job's metadata are promoted along with its configuration and used for
+// the update. Cron jobs are scheduled, replacing any previous schedule.
+func (r *RealisClient) Promote(key *aurora.JobKey, fromEnv string, toEnv string, overrides func(job *Job)) (*aurora.Response, error) {
+	if fromEnv == toEnv {
+		return nil, errors.Errorf("Job %s/%s cannot be promoted to the environment it is in", key.Role, key.Name)
+	}
+
+	source := &aurora.JobKey{Role: key.Role, Environment: fromEnv, Name: key.Name}
+	target := &aurora.JobKey{Role: key.Role, Environment: toEnv, Name: key.Name}
+	if err := ValidateJobKey(target); err != nil {
+		return nil, err
+	}
+
+	configs, err := r.GetJobs(key.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	config := findJobConfig(configs, source)
+	if config == nil {
+		return nil, errors.Errorf("Job %s/%s/%s not found", source.Role, source.Environment, source.Name)
+	}
+	exists := findJobConfig(configs, target) != nil
+
+	// The fetched configuration is ours to modify, only the key is shared with the task config.
+	config.Key = target
+	config.TaskConfig.Job = target
+	if err := rekeyThermosPayload(config.TaskConfig); err != nil {
+		return nil, err
+	}
+
+	job := NewJobFromConfig(config)
+	if overrides != nil {
+		overrides(job)
+	}
+
+	if err := r.authorize(&Operation{Name: "Promote", JobKey: target, Job: job}); err != nil {
+		return nil, err
+	}
+
+	if job.IsCron() {
+		return r.scheduleCronJob(job)
+	}
+
+	if !exists {
+		return r.CreateJob(job)
+	}
+
+	update, err := UpdateJobFromConfig(job.JobConfig())
+	if err != nil {
+		return nil, err
+	}
+	return r.StartJobUpdate(update, "Promoted from "+fromEnv)
+}
+
+func (r *RealisClient) scheduleCronJob(job *Job) (*aurora.Response, error) {
+	op := &Operation{Name: "ScheduleCronJob", JobKey: job.JobKey(), Job: job}
+	if err := r.authorize(op); err != nil {
+		return nil, err
+	}
+
+	response, err := r.thriftCallAs(op, "ScheduleCronJob", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.ScheduleCronJob(job.jobConfig)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error sending Cron Job Schedule message to Aurora Scheduler")
+	}
+
+	return response, nil
+}
+
+func findJobConfig(configs []*aurora.JobConfiguration, key *aurora.JobKey) *aurora.JobConfiguration {
+	for _, config := range configs {
+		if config.Key != nil && *config.Key == *key {
+			return config
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/pkg/errors"
+)
+
+// Thrift protocol used to encode calls to the scheduler.
+type Protocol int
+
+const (
+	// TJSONProtocol, the default, readable on the wire.
+	JSONProtocol Protocol = iota
+
+	// TBinaryProtocol, much smaller and cheaper to decode for big responses such as GetTasks.
+	BinaryProtocol
+)
+
+const binaryContentType = "application/vnd.apache.thrift.binary"
+
+func (p Protocol) String() string {
+	switch p {
+	case JSONProtocol:
+		return "json"
+	case BinaryProtocol:
+		return "binary"
+	}
+	return "unknown"
+}
+
+// Encode calls with the given protocol. The scheduler serves both on its API path, telling
+// them apart by the Content-Type header, which is set on HTTP transports accordingly. Other
+// transports must reach a server expecting the chosen protocol.
+func WithProtocol(config *RealisConfig, protocol Protocol) error {
+	if config == nil || config.transport == nil {
+		return errors.New("Configuration has no transport")
+	}
+
+	if protocol != JSONProtocol && protocol != BinaryProtocol {
+		return errors.Errorf("Unknown protocol %d", protocol)
+	}
+
+	config.protocol = protocol
+	if trans, ok := config.transport.(headerEditor); ok {
+		protocol.setHeaders(trans)
+	}
+	return nil
+}
+
+func (p Protocol) factory() thrift.TProtocolFactory {
+	if p == BinaryProtocol {
+		return thrift.NewTBinaryProtocolFactoryDefault()
+	}
+	return thrift.NewTJSONProtocolFactory()
+}
+
+// Announce the protocol of requests and the one expected in responses. JSON keeps the
+// transport's default content type, which the scheduler reads as JSON.
+func (p Protocol) setHeaders(trans headerEditor) {
+	if p != BinaryProtocol {
+		return
+	}
+
+	trans.DelHeader("Content-Type")
+	trans.DelHeader("Accept")
+	trans.SetHeader("Content-Type", binaryContentType)
+	trans.SetHeader("Accept", binaryContentType)
+}
@@ -0,0 +1,212 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mutation held back while the scheduler was unreachable.
+type QueuedOperation struct {
+	Name     string
+	Role     string
+	QueuedAt time.Time
+
+	id        int
+	operation *Operation
+	call      rpcFunc
+}
+
+// Settings of the mutation queue.
+type MutationQueueConfig struct {
+	// Operations held at most, further mutations fail while the queue is full.
+	MaxSize int
+
+	// How long an operation may wait before it is dropped.
+	TTL time.Duration
+
+	// Called for every operation dropped because it waited longer than TTL.
+	OnExpired func(op QueuedOperation)
+
+	// Called with the outcome of every queued operation once it is sent, or with the error of
+	// operations the policies, freeze calendar or approver reject by the time they are flushed.
+	OnFlushed func(op QueuedOperation, response *aurora.Response, err error)
+}
+
+// Returned by mutating calls that were queued instead of sent because the scheduler could not
+// be reached. The outcome is reported through the queue's OnFlushed callback.
+type MutationQueuedError struct {
+	Operation QueuedOperation
+	Err       error
+}
+
+func (e *MutationQueuedError) Error() string {
+	return fmt.Sprintf("%s queued until the scheduler is reachable again: %v", e.Operation.Name, e.Err)
+}
+
+func (e *MutationQueuedError) Cause() error {
+	return e.Err
+}
+
+type mutationQueue struct {
+	config MutationQueueConfig
+	lock   sync.Mutex
+	ops    []QueuedOperation
+	nextId int
+
+	// Held for a whole flush so concurrent flushes don't send the same operation twice.
+	flushing sync.Mutex
+}
+
+// Queue mutations issued while the scheduler is unreachable instead of failing them, and send
+// them in order once it is back, see RunMutationQueue. A mutation whose request reached the
+// scheduler before the connection broke may be applied twice, so this mode is best suited to
+// idempotent operations such as KillInstance. KillJob, RestartJob and RemoveInstances look up
+// the active instances first, so they fail instead of being queued while the scheduler is
+// unreachable.
+func (r *RealisClient) EnableMutationQueue(config MutationQueueConfig) {
+	r.mutations = &mutationQueue{config: config}
+}
+
+// Number of mutations waiting for the scheduler.
+func (r *RealisClient) QueuedMutations() int {
+	if r.mutations == nil {
+		return 0
+	}
+
+	r.mutations.lock.Lock()
+	defer r.mutations.lock.Unlock()
+	return len(r.mutations.ops)
+}
+
+// Try to send the queued mutations every interval until stop is closed.
+func (r *RealisClient) RunMutationQueue(interval time.Duration, stop <-chan struct{}) {
+	for {
+		r.FlushMutations()
+
+		select {
+		case <-stop:
+			return
+		case <-r.clock.After(interval):
+		}
+	}
+}
+
+// Drop the expired mutations and send the others in order, stopping at the first one that
+// finds the scheduler still unreachable.
+func (r *RealisClient) FlushMutations() {
+	queue := r.mutations
+	if queue == nil {
+		return
+	}
+
+	queue.flushing.Lock()
+	defer queue.flushing.Unlock()
+
+	for {
+		queue.lock.Lock()
+		if len(queue.ops) == 0 {
+			queue.lock.Unlock()
+			return
+		}
+		op := queue.ops[0]
+		queue.lock.Unlock()
+
+		if queue.config.TTL > 0 && r.clock.Now().Sub(op.QueuedAt) > queue.config.TTL {
+			queue.pop(op)
+			if queue.config.OnExpired != nil {
+				queue.config.OnExpired(op)
+			}
+			continue
+		}
+
+		// Rules may have changed while the operation was waiting, e.g. a freeze started.
+		if err := r.authorize(op.operation); err != nil {
+			queue.pop(op)
+			if queue.config.OnFlushed != nil {
+				queue.config.OnFlushed(op, nil, err)
+			}
+			continue
+		}
+
+		response, err := r.callAs(op.Role, op.Name, op.call)
+		if err != nil && schedulerUnavailable(err) {
+			return
+		}
+
+		queue.pop(op)
+		if queue.config.OnFlushed != nil {
+			queue.config.OnFlushed(op, response, err)
+		}
+	}
+}
+
+// Queue a mutation that failed because the scheduler is unreachable. Returns false if the
+// queue is full.
+func (q *mutationQueue) push(op QueuedOperation) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.config.MaxSize > 0 && len(q.ops) >= q.config.MaxSize {
+		return false
+	}
+	q.nextId++
+	op.id = q.nextId
+	q.ops = append(q.ops, op)
+	return true
+}
+
+// Remove an operation once it was sent, dropped or rejected.
+func (q *mutationQueue) pop(op QueuedOperation) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for i := range q.ops {
+		if q.ops[i].id == op.id {
+			q.ops = append(q.ops[:i], q.ops[i+1:]...)
+			return
+		}
+	}
+}
+
+// Whether err means the scheduler could not be reached, as opposed to a rejected call: a
+// network or transport failure, or a 502, 503 or 504 from a proxy in front of it.
+func schedulerUnavailable(err error) bool {
+	message := err.Error()
+	for _, code := range []string{"502", "503", "504"} {
+		if strings.Contains(message, "HTTP Response code: "+code) {
+			return true
+		}
+	}
+	return diagnoseConnectionError(err).Problem == NetworkProblem
+}
+
+func (r *RealisClient) queueMutation(operation *Operation, name string, call rpcFunc, err error) error {
+	op := QueuedOperation{
+		Name:      name,
+		Role:      operation.JobKey.Role,
+		QueuedAt:  r.clock.Now(),
+		operation: operation,
+		call:      call}
+	if !r.mutations.push(op) {
+		return errors.Wrap(err, "Scheduler unreachable and the mutation queue is full")
+	}
+	return &MutationQueuedError{Operation: op, Err: err}
+}
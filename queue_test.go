@@ -0,0 +1,184 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMutationQueuePop(t *testing.T) {
+	tests := []struct {
+		name string
+		pop  []int
+		left []string
+	}{
+		{"head", []int{0}, []string{"b", "c"}},
+		{"middle", []int{1}, []string{"a", "c"}},
+		{"twice", []int{1, 1}, []string{"a", "c"}},
+		{"all", []int{2, 0, 1}, []string{}},
+	}
+
+	for _, test := range tests {
+		queue := &mutationQueue{}
+		var pushed []QueuedOperation
+		for _, name := range []string{"a", "b", "c"} {
+			queue.push(QueuedOperation{Name: name})
+			pushed = append(pushed, queue.ops[len(queue.ops)-1])
+		}
+
+		for _, i := range test.pop {
+			queue.pop(pushed[i])
+		}
+
+		left := []string{}
+		for _, op := range queue.ops {
+			left = append(left, op.Name)
+		}
+		if !reflect.DeepEqual(left, test.left) {
+			t.Errorf("%s: left %v, want %v", test.name, left, test.left)
+		}
+	}
+}
+
+func TestMutationQueueFull(t *testing.T) {
+	queue := &mutationQueue{config: MutationQueueConfig{MaxSize: 2}}
+	for i, want := range []bool{true, true, false} {
+		if pushed := queue.push(QueuedOperation{Name: "killTasks"}); pushed != want {
+			t.Errorf("push %d: %v, want %v", i, pushed, want)
+		}
+	}
+}
+
+// Scheduler double whose mutations fail as unreachable until it is brought back.
+type flakyScheduler struct {
+	lock      sync.Mutex
+	reachable bool
+	sent      []string
+}
+
+func (s *flakyScheduler) call(name string) rpcFunc {
+	return func(*connection) (*aurora.Response, error) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		if !s.reachable {
+			return nil, errors.New("HTTP Response code: 503")
+		}
+		s.sent = append(s.sent, name)
+		return &aurora.Response{ResponseCode: aurora.ResponseCode_OK}, nil
+	}
+}
+
+func TestFlushMutations(t *testing.T) {
+	denyAll := func(op *Operation) error { return errors.New("frozen") }
+
+	tests := []struct {
+		name      string
+		reachable bool
+		elapsed   time.Duration
+		policy    Policy
+		sent      []string
+		expired   []string
+		rejected  []string
+		left      int
+	}{
+		{name: "unreachable", left: 2},
+		{name: "reachable", reachable: true, sent: []string{"first", "second"}},
+		{name: "expired", reachable: true, elapsed: 2 * time.Minute, expired: []string{"first", "second"}},
+		{name: "within ttl", reachable: true, elapsed: 30 * time.Second, sent: []string{"first", "second"}},
+		{name: "rejected", reachable: true, policy: denyAll, rejected: []string{"first", "second"}},
+	}
+
+	for _, test := range tests {
+		clock := newFakeClock()
+		r := newTestClient(t, clock)
+		scheduler := &flakyScheduler{}
+
+		var expired, rejected []string
+		var flushed []string
+		r.EnableMutationQueue(MutationQueueConfig{
+			TTL:       time.Minute,
+			OnExpired: func(op QueuedOperation) { expired = append(expired, op.Name) },
+			OnFlushed: func(op QueuedOperation, response *aurora.Response, err error) {
+				if err != nil {
+					rejected = append(rejected, op.Name)
+				} else {
+					flushed = append(flushed, op.Name)
+				}
+			}})
+
+		for _, name := range []string{"first", "second"} {
+			op := &Operation{Name: "killTasks", JobKey: &aurora.JobKey{Role: "vagrant", Environment: "prod", Name: name}}
+			_, err := r.thriftCallAs(op, name, scheduler.call(name))
+			if _, ok := err.(*MutationQueuedError); !ok {
+				t.Fatalf("%s: %s not queued: %v", test.name, name, err)
+			}
+		}
+
+		if test.policy != nil {
+			r.AddPolicy(test.policy)
+		}
+		clock.Sleep(test.elapsed)
+		scheduler.reachable = test.reachable
+		r.FlushMutations()
+
+		if !reflect.DeepEqual(scheduler.sent, test.sent) || !reflect.DeepEqual(flushed, test.sent) {
+			t.Errorf("%s: sent %v and flushed %v, want %v", test.name, scheduler.sent, flushed, test.sent)
+		}
+		if !reflect.DeepEqual(expired, test.expired) {
+			t.Errorf("%s: expired %v, want %v", test.name, expired, test.expired)
+		}
+		if !reflect.DeepEqual(rejected, test.rejected) {
+			t.Errorf("%s: rejected %v, want %v", test.name, rejected, test.rejected)
+		}
+		if left := r.QueuedMutations(); left != test.left {
+			t.Errorf("%s: %d mutations left, want %d", test.name, left, test.left)
+		}
+	}
+}
+
+func TestConcurrentFlushMutations(t *testing.T) {
+	r := newTestClient(t, newFakeClock())
+	scheduler := &flakyScheduler{}
+	r.EnableMutationQueue(MutationQueueConfig{})
+
+	for i := 0; i < 20; i++ {
+		op := &Operation{Name: "killTasks", JobKey: &aurora.JobKey{Role: "vagrant"}}
+		r.thriftCallAs(op, "killTasks", scheduler.call("killTasks"))
+	}
+
+	scheduler.reachable = true
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.FlushMutations()
+		}()
+	}
+	wg.Wait()
+
+	if len(scheduler.sent) != 20 {
+		t.Errorf("sent %d mutations, want 20", len(scheduler.sent))
+	}
+	if left := r.QueuedMutations(); left != 0 {
+		t.Errorf("%d mutations left, want 0", left)
+	}
+}
@@ -17,38 +17,179 @@ package realis
 
 import (
 	"encoding/base64"
-	"fmt"
 	"gen-go/apache/aurora"
 	"git.apache.org/thrift.git/lib/go/thrift"
 	"github.com/pkg/errors"
 	"net/http"
 	"net/http/cookiejar"
-	"os"
+	"sort"
+	"sync"
 	"time"
 )
 
-type Realis struct {
-	client *aurora.AuroraSchedulerManagerClient
+// Scheduler operations of the client. Code written against this interface can be tested with
+// the in-memory implementation of the mock package instead of a live cluster.
+type Realis interface {
+	CreateJob(auroraJob *Job) (*aurora.Response, error)
+	KillJob(key *aurora.JobKey) (*aurora.Response, error)
+	KillInstance(key *aurora.JobKey, instanceId int32) (*aurora.Response, error)
+	RestartJob(key *aurora.JobKey) (*aurora.Response, error)
+	AddInstances(instKey *aurora.InstanceKey, count int32) (*aurora.Response, error)
+	RemoveInstances(key *aurora.JobKey, count int32) (*aurora.Response, error)
+
+	StartJobUpdate(updateJob *UpdateJob, message string) (*aurora.Response, error)
+	AbortJobUpdate(key *aurora.JobKey, updateId string, message string) (*aurora.Response, error)
+	PauseJobUpdate(key *aurora.JobUpdateKey, message string) (*aurora.Response, error)
+	ResumeJobUpdate(key *aurora.JobUpdateKey, message string) (*aurora.Response, error)
+	JobUpdateDetails(key *aurora.JobUpdateKey) (*aurora.JobUpdateDetails, error)
+
+	GetTaskStatus(query *aurora.TaskQuery) ([]*aurora.ScheduledTask, error)
+	GetTasksWithoutConfigs(query *aurora.TaskQuery) ([]*aurora.ScheduledTask, error)
+	GetJobs(role string) ([]*aurora.JobConfiguration, error)
+	GetJobSummaries(role string) ([]*aurora.JobSummary, error)
+	GetQuota(role string) (*aurora.GetQuotaResult_, error)
+	GetTierConfigs() (*aurora.GetTierConfigResult_, error)
+	MaintenanceStatus(hosts ...string) (map[string]aurora.MaintenanceMode, error)
+	DrainHosts(hosts ...string) (*aurora.Response, error)
+	EndMaintenance(hosts ...string) (*aurora.Response, error)
+
+	Close() error
+}
+
+var _ Realis = (*RealisClient)(nil)
+
+// Client talking to the scheduler over thrift, implementing Realis along with higher level
+// helpers such as monitors, autoscalers and reports. Safe for concurrent use once configured,
+// calls made concurrently go out over separate connections, see SetMaxConnections.
+type RealisClient struct {
+	config    RealisConfig
+	events    *eventStream[Event]
+	policies  []Policy
+	approver  Approver
+	freeze    FreezeCalendar
+	costRates *CostRates
+	polling   PollingPolicy
+	clock     Clock
+	queries   flightGroup
+	closeOnce sync.Once
+	closeErr  error
+	mutations *mutationQueue
+	retries   RetryPolicy
+	leaders   *leaderTracker
+
+	logger      Logger
+	debugRPCs   bool
+	metricsHook UpdateMetricsHook
+
+	checkResponses bool
+
+	// Guards the settings below, which may be changed while calls are made.
+	settingsLock    sync.RWMutex
+	verifyTimeout   time.Duration
+	observerPort    int
+	roleCredentials map[string]Credentials
+
+	// Guards the pool and the scheduler the client talks to, which changes on failover.
+	pool     *connectionPool
+	poolLock sync.Mutex
 }
 
 // Wrap object to provide future flexibility
 type RealisConfig struct {
-	transport thrift.TTransport
+	transport  thrift.TTransport
+	url        string
+	apiPath    string
+	httpClient *http.Client
+	failover   *failoverTransport
+	resolver   LeaderResolver
+	protocol   Protocol
+	limits     ThriftLimits
+}
+
+// Implemented by transports that carry HTTP headers, such as *thrift.THttpClient.
+type headerSetter interface {
+	SetHeader(key string, value string)
 }
 
-// Create a new Client with a default transport layer
-func NewClient(config RealisConfig) *Realis {
+// Create a new Client with a default transport layer. Fails if the configuration has no
+// transport, e.g. a zero RealisConfig.
+func NewClient(config RealisConfig) (*RealisClient, error) {
+
+	if config.transport == nil {
+		return nil, errors.New("Configuration has no transport, create it with one of the NewConfig functions")
+	}
+
+	if httpTrans, ok := config.transport.(headerSetter); ok {
+		httpTrans.SetHeader("User-Agent", "GoRealis v0.1")
+	}
 
-	httpTrans := (config.transport).(*thrift.THttpClient)
-	httpTrans.SetHeader("User-Agent", "GoRealis v0.1")
+	// Outermost so it sees the scheduler that finally served each call. Clients created from
+	// the same configuration share its http.Client, so the tracker wraps a copy of it.
+	var leaders *leaderTracker
+	if config.httpClient != nil {
+		httpClient := *config.httpClient
+		config.httpClient = &httpClient
+
+		if config.httpClient.Transport == nil {
+			if _, err := baseTransport(&config); err != nil {
+				return nil, err
+			}
+		}
+		leaders = &leaderTracker{next: config.httpClient.Transport}
+		config.httpClient.Transport = leaders
+	}
 
-	protocolFactory := thrift.NewTJSONProtocolFactory()
+	r := &RealisClient{
+		config:  config,
+		events:  newEventStream[Event](eventBufferSize, DropNewest),
+		clock:   realClock{},
+		logger:  NoopLogger{},
+		leaders: leaders}
+	r.SetMaxConnections(defaultMaxConnections)
+
+	// The configured transport sends through the shared http.Client, replace it with one
+	// carrying the same headers over the copy.
+	if r.pooled() {
+		trans, err := r.newTransport(config.url)
+		if err != nil {
+			return nil, err
+		}
+		r.config.transport = trans
+	}
 
-	return &Realis{client: aurora.NewAuroraSchedulerManagerClientFactory(config.transport, protocolFactory)}
+	return r, nil
 }
 
-// Create a default configuration of the transport layer, requires a URL to test connection with.
+// Create a default configuration of the transport layer for the scheduler at the given URL.
+// The URL may be given as host, host:port or a full URL with or without the API path.
+// No connection is made, use VerifyConnection on the client to check connectivity.
 func NewDefaultConfig(url string) (RealisConfig, error) {
+	return NewConfigWithRoundTripper(url, nil)
+}
+
+// Create a configuration whose HTTP requests are sent through the given RoundTripper, allowing
+// custom HTTP stacks. A nil RoundTripper uses http.DefaultTransport.
+func NewConfigWithRoundTripper(url string, roundTripper http.RoundTripper) (RealisConfig, error) {
+	return newHTTPConfig(url, defaultAPIPath, roundTripper)
+}
+
+// Create a default configuration for schedulers serving their API somewhere other than /api,
+// e.g. "/aurora/api" for clusters mounted behind a path-rewriting proxy.
+func NewDefaultConfigWithAPIPath(url string, apiPath string) (RealisConfig, error) {
+	apiPath, err := normalizeAPIPath(apiPath)
+	if err != nil {
+		return RealisConfig{}, err
+	}
+
+	return newHTTPConfig(url, apiPath, nil)
+}
+
+func newHTTPConfig(url string, apiPath string, roundTripper http.RoundTripper) (RealisConfig, error) {
+	url, err := normalizeURL(url, apiPath)
+	if err != nil {
+		return RealisConfig{}, err
+	}
+
 	jar, err := cookiejar.New(nil)
 
 	if err != nil {
@@ -56,26 +197,42 @@ func NewDefaultConfig(url string) (RealisConfig, error) {
 	}
 
 	//Custom client to timeout after 10 seconds to avoid hanging
-	trans, err := thrift.NewTHttpPostClientWithOptions(url+"/api",
-		thrift.THttpClientOptions{Client: &http.Client{Timeout: time.Second * 10, Jar: jar}})
+	httpClient := &http.Client{
+		Timeout:       time.Second * 10,
+		Jar:           jar,
+		Transport:     roundTripper,
+		CheckRedirect: checkRedirect}
+	trans, err := thrift.NewTHttpPostClientWithOptions(url+apiPath,
+		thrift.THttpClientOptions{Client: httpClient})
 
 	if err != nil {
 		return RealisConfig{}, errors.Wrap(err, "Error creating transport.")
 	}
 
-	if err := trans.Open(); err != nil {
-		fmt.Fprintln(os.Stderr)
-		return RealisConfig{}, errors.Wrapf(err, "Error opening connection to %s.", url)
-	}
+	return RealisConfig{transport: trans, url: url, apiPath: apiPath, httpClient: httpClient}, nil
 
-	return RealisConfig{transport: trans}, nil
+}
 
+// Create a configuration around an arbitrary thrift transport, e.g. a test double. HTTP
+// specific features such as basic authorization are not available for non-HTTP transports.
+func NewConfigWithTransport(transport thrift.TTransport) RealisConfig {
+	return RealisConfig{transport: transport}
 }
 
-// Helper function to add basic authorization needed to communicate with Apache Aurora.
-func AddBasicAuth(config *RealisConfig, username string, password string) {
-	httpTrans := (config.transport).(*thrift.THttpClient)
+// Helper function to add basic authorization needed to communicate with Apache Aurora. Fails
+// if the configuration's transport doesn't carry HTTP headers.
+func AddBasicAuth(config *RealisConfig, username string, password string) error {
+	if config == nil || config.transport == nil {
+		return errors.New("Configuration has no transport")
+	}
+
+	httpTrans, ok := config.transport.(headerSetter)
+	if !ok {
+		return errors.New("Basic authorization requires an HTTP transport")
+	}
+
 	httpTrans.SetHeader("Authorization", "Basic "+basicAuth(username, password))
+	return nil
 }
 
 func basicAuth(username, password string) string {
@@ -83,19 +240,37 @@ func basicAuth(username, password string) string {
 	return base64.StdEncoding.EncodeToString([]byte(auth))
 }
 
-// Releases resources associated with the realis client.
-func (r *Realis) Close() {
-	r.client.Transport.Close()
+// Releases resources associated with the realis client. Safe to call more than once and on a
+// nil client, later calls return the result of the first one.
+func (r *RealisClient) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.closeOnce.Do(func() {
+		r.poolLock.Lock()
+		defer r.poolLock.Unlock()
+
+		r.resetConnections()
+		r.pool.closed = true
+
+		if r.config.transport != nil {
+			r.closeErr = r.config.transport.Close()
+		}
+	})
+	return r.closeErr
 }
 
 // Uses predefined set of states to retrieve a set of active jobs in Apache Aurora.
-func (r *Realis) getActiveInstanceIds(key *aurora.JobKey) (map[int32]bool, error) {
+func (r *RealisClient) getActiveInstanceIds(key *aurora.JobKey) (map[int32]bool, error) {
 	taskQ := &aurora.TaskQuery{Role: key.Role,
 		Environment: key.Environment,
 		JobName:     key.Name,
 		Statuses:    aurora.ACTIVE_STATES}
 
-	response, err := r.client.GetTasksWithoutConfigs(taskQ)
+	response, err := r.thriftCall("GetTasksWithoutConfigs", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetTasksWithoutConfigs(taskQ)
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "Error querying Aurora Scheduler")
 	}
@@ -111,22 +286,38 @@ func (r *Realis) getActiveInstanceIds(key *aurora.JobKey) (map[int32]bool, error
 }
 
 // Kill a specific instance of a job.
-func (r *Realis) KillInstance(key *aurora.JobKey, instanceId int32) (*aurora.Response, error) {
+func (r *RealisClient) KillInstance(key *aurora.JobKey, instanceId int32) (*aurora.Response, error) {
+
+	op := &Operation{Name: "KillInstance", JobKey: key}
+	if err := r.authorize(op); err != nil {
+		return nil, err
+	}
 
 	instanceIds := make(map[int32]bool)
 	instanceIds[instanceId] = true
 
-	response, err := r.client.KillTasks(key, instanceIds)
+	response, err := r.thriftCallAs(op, "KillTasks", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.KillTasks(key, instanceIds)
+	})
 
 	if err != nil {
 		return nil, errors.Wrap(err, "Error sending Kill command to Aurora Scheduler.")
 	}
 
+	if isUpdateInProgress(response) {
+		return response, r.updateInProgressError(key, response)
+	}
+
 	return response, nil
 }
 
 // Sends a kill message to the scheduler for all active tasks under a job.
-func (r *Realis) KillJob(key *aurora.JobKey) (*aurora.Response, error) {
+func (r *RealisClient) KillJob(key *aurora.JobKey) (*aurora.Response, error) {
+
+	op := &Operation{Name: "KillJob", JobKey: key}
+	if err := r.authorize(op); err != nil {
+		return nil, err
+	}
 
 	instanceIds, err := r.getActiveInstanceIds(key)
 	if err != nil {
@@ -134,12 +325,22 @@ func (r *Realis) KillJob(key *aurora.JobKey) (*aurora.Response, error) {
 	}
 
 	if len(instanceIds) > 0 {
-		response, err := r.client.KillTasks(key, instanceIds)
+		response, err := r.thriftCallAs(op, "KillTasks", func(conn *connection) (*aurora.Response, error) {
+			return conn.client.KillTasks(key, instanceIds)
+		})
 
 		if err != nil {
 			return nil, errors.Wrap(err, "Error sending Kill command to Aurora Scheduler.")
 		}
 
+		if isUpdateInProgress(response) {
+			return response, r.updateInProgressError(key, response)
+		}
+
+		if r.verifyMutationsTimeout() > 0 && response.ResponseCode == aurora.ResponseCode_OK {
+			return response, r.verifyKill(key, instanceIds)
+		}
+
 		return response, nil
 	} else {
 		return nil, errors.New("No tasks in the Active state.")
@@ -147,18 +348,34 @@ func (r *Realis) KillJob(key *aurora.JobKey) (*aurora.Response, error) {
 }
 
 // Sends a create job message to the scheduler with a specific job configuration.
-func (r *Realis) CreateJob(auroraJob *Job) (*aurora.Response, error) {
-	response, err := r.client.CreateJob(auroraJob.jobConfig)
+func (r *RealisClient) CreateJob(auroraJob *Job) (*aurora.Response, error) {
+	op := &Operation{Name: "CreateJob", JobKey: auroraJob.JobKey(), Job: auroraJob}
+	if err := r.authorize(op); err != nil {
+		return nil, err
+	}
+
+	response, err := r.thriftCallAs(op, "CreateJob", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.CreateJob(auroraJob.jobConfig)
+	})
 
 	if err != nil {
 		return nil, errors.Wrap(err, "Error sending Create command to Aurora Scheduler.")
 	}
 
+	if r.verifyMutationsTimeout() > 0 && response.ResponseCode == aurora.ResponseCode_OK {
+		return response, r.verifyCreate(auroraJob.JobKey())
+	}
+
 	return response, nil
 }
 
 // Restarts all active tasks under a job configuration.
-func (r *Realis) RestartJob(key *aurora.JobKey) (*aurora.Response, error) {
+func (r *RealisClient) RestartJob(key *aurora.JobKey) (*aurora.Response, error) {
+
+	op := &Operation{Name: "RestartJob", JobKey: key}
+	if err := r.authorize(op); err != nil {
+		return nil, err
+	}
 
 	instanceIds, err := r.getActiveInstanceIds(key)
 	if err != nil {
@@ -166,12 +383,18 @@ func (r *Realis) RestartJob(key *aurora.JobKey) (*aurora.Response, error) {
 	}
 
 	if len(instanceIds) > 0 {
-		response, err := r.client.RestartShards(key, instanceIds)
+		response, err := r.thriftCallAs(op, "RestartShards", func(conn *connection) (*aurora.Response, error) {
+			return conn.client.RestartShards(key, instanceIds)
+		})
 
 		if err != nil {
 			return nil, errors.Wrap(err, "Error sending Restart command to Aurora Scheduler.")
 		}
 
+		if isUpdateInProgress(response) {
+			return response, r.updateInProgressError(key, response)
+		}
+
 		return response, nil
 	} else {
 		return nil, errors.New("No tasks in the Active state.")
@@ -179,24 +402,51 @@ func (r *Realis) RestartJob(key *aurora.JobKey) (*aurora.Response, error) {
 }
 
 // Update all tasks under a job configuration. Currently there's no support for canary deployments.
-func (r *Realis) StartJobUpdate(updateJob *UpdateJob, message string) (*aurora.Response, error) {
+func (r *RealisClient) StartJobUpdate(updateJob *UpdateJob, message string) (*aurora.Response, error) {
+
+	op := &Operation{
+		Name:     "StartJobUpdate",
+		JobKey:   updateJob.JobKey(),
+		Job:      updateJob.Job,
+		Override: updateJob.overrideWindow}
+	if err := r.authorize(op); err != nil {
+		return nil, err
+	}
 
-	response, err := r.client.StartJobUpdate(updateJob.req, message)
+	response, err := r.thriftCallAs(op, "StartJobUpdate", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.StartJobUpdate(updateJob.req, message)
+	})
 
 	if err != nil {
 		return nil, errors.Wrap(err, "Error sending StartJobUpdate command to Aurora Scheduler.")
 	}
 
+	if isUpdateInProgress(response) {
+		return response, r.updateInProgressError(updateJob.JobKey(), response)
+	}
+
 	return response, nil
 }
 
 // Abort Job Update on Aurora. Requires the updateId which can be obtained on the Aurora web UI.
-func (r *Realis) AbortJobUpdate(
+func (r *RealisClient) AbortJobUpdate(
 	key *aurora.JobKey,
 	updateId string,
 	message string) (*aurora.Response, error) {
 
-	response, err := r.client.AbortJobUpdate(&aurora.JobUpdateKey{key, updateId}, message)
+	updateKey, err := NewJobUpdateKey(key, updateId)
+	if err != nil {
+		return nil, err
+	}
+
+	op := &Operation{Name: "AbortJobUpdate", JobKey: key}
+	if err := r.authorize(op); err != nil {
+		return nil, err
+	}
+
+	response, err := r.thriftCallAs(op, "AbortJobUpdate", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.AbortJobUpdate(updateKey, message)
+	})
 
 	if err != nil {
 		return nil, errors.Wrap(err, "Error sending AbortJobUpdate command to Aurora Scheduler.")
@@ -205,15 +455,280 @@ func (r *Realis) AbortJobUpdate(
 	return response, nil
 }
 
+// Pause an update in progress. It can be resumed later with ResumeJobUpdate.
+func (r *RealisClient) PauseJobUpdate(key *aurora.JobUpdateKey, message string) (*aurora.Response, error) {
+
+	if err := ValidateJobUpdateKey(key); err != nil {
+		return nil, err
+	}
+
+	op := &Operation{Name: "PauseJobUpdate", JobKey: key.Job}
+	if err := r.authorize(op); err != nil {
+		return nil, err
+	}
+
+	response, err := r.thriftCallAs(op, "PauseJobUpdate", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.PauseJobUpdate(key, message)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Error sending PauseJobUpdate command to Aurora Scheduler.")
+	}
+
+	return response, nil
+}
+
+// Resume an update previously paused.
+func (r *RealisClient) ResumeJobUpdate(key *aurora.JobUpdateKey, message string) (*aurora.Response, error) {
+
+	if err := ValidateJobUpdateKey(key); err != nil {
+		return nil, err
+	}
+
+	op := &Operation{Name: "ResumeJobUpdate", JobKey: key.Job}
+	if err := r.authorize(op); err != nil {
+		return nil, err
+	}
+
+	response, err := r.thriftCallAs(op, "ResumeJobUpdate", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.ResumeJobUpdate(key, message)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Error sending ResumeJobUpdate command to Aurora Scheduler.")
+	}
+
+	return response, nil
+}
+
 // Scale up the number of instances under a job configuration using the configuration for specific
 // instance to scale up.
-func (r *Realis) AddInstances(instKey *aurora.InstanceKey, count int32) (*aurora.Response, error) {
+func (r *RealisClient) AddInstances(instKey *aurora.InstanceKey, count int32) (*aurora.Response, error) {
 
-	response, err := r.client.AddInstances(instKey, count)
+	if err := ValidateInstanceKey(instKey); err != nil {
+		return nil, err
+	}
+
+	op := &Operation{Name: "AddInstances", JobKey: instKey.JobKey}
+	if err := r.authorize(op); err != nil {
+		return nil, err
+	}
+
+	response, err := r.thriftCallAs(op, "AddInstances", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.AddInstances(instKey, count)
+	})
 
 	if err != nil {
 		return nil, errors.Wrap(err, "Error sending AddInstances command to Aurora Scheduler.")
 	}
 
+	if isUpdateInProgress(response) {
+		return response, r.updateInProgressError(instKey.JobKey, response)
+	}
+
 	return response, nil
 }
+
+// Scale down the number of instances under a job configuration by killing the instances
+// with the highest instance ids.
+func (r *RealisClient) RemoveInstances(key *aurora.JobKey, count int32) (*aurora.Response, error) {
+
+	op := &Operation{Name: "RemoveInstances", JobKey: key}
+	if err := r.authorize(op); err != nil {
+		return nil, err
+	}
+
+	instanceIds, err := r.getActiveInstanceIds(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not retrieve relevant task instance IDs.")
+	}
+
+	if int(count) > len(instanceIds) {
+		return nil, errors.Errorf("Unable to remove %d instances, job only has %d active", count, len(instanceIds))
+	}
+
+	ids := make([]int32, 0, len(instanceIds))
+	for id := range instanceIds {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+
+	toKill := make(map[int32]bool)
+	for _, id := range ids[:count] {
+		toKill[id] = true
+	}
+
+	response, err := r.thriftCallAs(op, "KillTasks", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.KillTasks(key, toKill)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Error sending Kill command to Aurora Scheduler.")
+	}
+
+	if isUpdateInProgress(response) {
+		return response, r.updateInProgressError(key, response)
+	}
+
+	return response, nil
+}
+
+// Retrieve summaries of the updates currently active for a job.
+func (r *RealisClient) activeUpdateSummaries(key *aurora.JobKey) ([]*aurora.JobUpdateSummary, error) {
+	response, err := r.thriftCall("GetJobUpdateSummaries", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetJobUpdateSummaries(&aurora.JobUpdateQuery{
+			JobKey:         key,
+			UpdateStatuses: aurora.ACTIVE_JOB_UPDATE_STATES})
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for active updates")
+	}
+
+	if response.Result_ == nil || response.Result_.GetJobUpdateSummariesResult_ == nil {
+		return nil, nil
+	}
+
+	return response.Result_.GetJobUpdateSummariesResult_.UpdateSummaries, nil
+}
+
+// Retrieve the tasks matching a query, including their task configurations. Identical
+// concurrent queries share a single RPC, so the tasks returned must not be modified.
+func (r *RealisClient) GetTaskStatus(query *aurora.TaskQuery) ([]*aurora.ScheduledTask, error) {
+	response, err := r.queries.do("GetTasksStatus "+taskQueryKey(query), func() (*aurora.Response, error) {
+		return r.thriftCall("GetTasksStatus", func(conn *connection) (*aurora.Response, error) {
+			return conn.client.GetTasksStatus(query)
+		})
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for task status")
+	}
+
+	return scheduledTasks(response), nil
+}
+
+// Retrieve the tasks matching a query without their task configurations, which is
+// considerably cheaper for the scheduler. Identical concurrent queries share a single RPC, so
+// the tasks returned must not be modified.
+func (r *RealisClient) GetTasksWithoutConfigs(query *aurora.TaskQuery) ([]*aurora.ScheduledTask, error) {
+	response, err := r.queries.do("GetTasksWithoutConfigs "+taskQueryKey(query), func() (*aurora.Response, error) {
+		return r.thriftCall("GetTasksWithoutConfigs", func(conn *connection) (*aurora.Response, error) {
+			return conn.client.GetTasksWithoutConfigs(query)
+		})
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for task status")
+	}
+
+	return scheduledTasks(response), nil
+}
+
+func scheduledTasks(response *aurora.Response) []*aurora.ScheduledTask {
+	if response.Result_ == nil || response.Result_.ScheduleStatusResult_ == nil {
+		return nil
+	}
+	return response.Result_.ScheduleStatusResult_.Tasks
+}
+
+// Retrieve the details of an update, including its update and instance events.
+func (r *RealisClient) JobUpdateDetails(key *aurora.JobUpdateKey) (*aurora.JobUpdateDetails, error) {
+	if err := ValidateJobUpdateKey(key); err != nil {
+		return nil, err
+	}
+
+	response, err := r.thriftCall("GetJobUpdateDetails", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetJobUpdateDetails(key)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for update details")
+	}
+
+	if response.Result_ == nil || response.Result_.GetJobUpdateDetailsResult_ == nil ||
+		response.Result_.GetJobUpdateDetailsResult_.Details == nil {
+		return nil, errors.Errorf("Update %s not found: %s", key.ID, responseMessage(response))
+	}
+
+	return response.Result_.GetJobUpdateDetailsResult_.Details, nil
+}
+
+// Retrieve the tiers configured in the scheduler along with the name of the default tier.
+func (r *RealisClient) GetTierConfigs() (*aurora.GetTierConfigResult_, error) {
+	response, err := r.thriftCall("GetTierConfigs", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetTierConfigs()
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for tier configurations")
+	}
+
+	if response.Result_ == nil || response.Result_.GetTierConfigResult_ == nil {
+		return nil, errors.Errorf("No tier configurations returned: %s", responseMessage(response))
+	}
+
+	return response.Result_.GetTierConfigResult_, nil
+}
+
+// Retrieve summaries of all the jobs owned by a role.
+func (r *RealisClient) GetJobSummaries(role string) ([]*aurora.JobSummary, error) {
+	response, err := r.thriftCall("GetJobSummary", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetJobSummary(role)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for job summaries")
+	}
+
+	if response.Result_ == nil || response.Result_.JobSummaryResult_ == nil {
+		return nil, nil
+	}
+
+	summaries := make([]*aurora.JobSummary, 0, len(response.Result_.JobSummaryResult_.Summaries))
+	for summary := range response.Result_.JobSummaryResult_.Summaries {
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// Retrieve the quota of a role along with its current consumption.
+func (r *RealisClient) GetQuota(role string) (*aurora.GetQuotaResult_, error) {
+	response, err := r.thriftCall("GetQuota", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetQuota(role)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for quota")
+	}
+
+	if response.Result_ == nil || response.Result_.GetQuotaResult_ == nil {
+		return nil, errors.Errorf("No quota returned for role %s: %s", role, responseMessage(response))
+	}
+
+	return response.Result_.GetQuotaResult_, nil
+}
+
+// Retrieve the configurations of all jobs owned by a role, or of every job in the cluster
+// when role is empty.
+func (r *RealisClient) GetJobs(role string) ([]*aurora.JobConfiguration, error) {
+	response, err := r.thriftCall("GetJobs", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetJobs(role)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for jobs")
+	}
+
+	if response.Result_ == nil || response.Result_.GetJobsResult_ == nil {
+		return nil, nil
+	}
+
+	configs := make([]*aurora.JobConfiguration, 0, len(response.Result_.GetJobsResult_.Configs))
+	for config := range response.Result_.GetJobsResult_.Configs {
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
@@ -0,0 +1,61 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Redirects followed for a single request before giving up.
+const maxRedirects = 10
+
+// Returned when schedulers or the proxies in front of them redirect a request in a loop, as
+// happens with misconfigured proxies during a failover.
+type RedirectLoopError struct {
+	// URLs visited, in order, ending with the one that closed the loop or went over the limit.
+	Chain []string
+}
+
+func (e *RedirectLoopError) Error() string {
+	return fmt.Sprintf("Redirect loop between schedulers: %s", strings.Join(e.Chain, " -> "))
+}
+
+// Whether err was caused by a redirect loop.
+func IsRedirectLoop(err error) bool {
+	for _, e := range errorChain(err) {
+		if _, ok := e.(*RedirectLoopError); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop following redirects as soon as a URL is visited twice or the limit is reached.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	chain := make([]string, 0, len(via)+1)
+	looped := false
+	for _, previous := range via {
+		chain = append(chain, previous.URL.String())
+		looped = looped || previous.URL.String() == req.URL.String()
+	}
+	chain = append(chain, req.URL.String())
+
+	if looped || len(via) >= maxRedirects {
+		return &RedirectLoopError{Chain: chain}
+	}
+	return nil
+}
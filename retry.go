@@ -0,0 +1,101 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/pkg/errors"
+	"math/rand"
+	"time"
+)
+
+// How RPCs failing with a transient error are retried. Each retry waits twice as long as the
+// previous one, starting at BaseDelay and up to MaxDelay.
+type RetryPolicy struct {
+	// Attempts made in total, including the first one. Values below 2 disable retries.
+	MaxAttempts int
+
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Fraction of the delay randomly added or removed, e.g. 0.1 for +/-10%.
+	Jitter float64
+
+	// Whether a failed call should be retried, IsTransient when nil.
+	Retryable func(response *aurora.Response, err error) bool
+}
+
+// Retry policy used when none is set: every call is attempted once.
+var NoRetries = RetryPolicy{MaxAttempts: 1}
+
+// Retry every RPC made by the client according to the policy, sparing callers their own loops.
+// Mutations are retried too, so a mutation whose request reached the scheduler before the
+// connection broke may be applied twice.
+func (r *RealisClient) SetRetryPolicy(policy RetryPolicy) {
+	r.retries = policy
+}
+
+// Whether a call failed with a transient error worth retrying: the scheduler could not be
+// reached, the transport timed out, or the scheduler answered ERROR_TRANSIENT.
+func IsTransient(response *aurora.Response, err error) bool {
+	if err == nil {
+		return response != nil && response.ResponseCode == aurora.ResponseCode_ERROR_TRANSIENT
+	}
+
+	if transportErr, ok := errors.Cause(err).(thrift.TTransportException); ok && transportErr.TypeId() == thrift.TIMED_OUT {
+		return true
+	}
+	return schedulerUnavailable(err)
+}
+
+// Invoke an RPC, with leader failover, retrying it according to the client's retry policy.
+func (r *RealisClient) withRetries(name string, invoke func() (*aurora.Response, error)) (*aurora.Response, error) {
+	policy := r.retries
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+
+	delay := policy.BaseDelay
+	for attempt := 1; ; attempt++ {
+		response, err := r.withFailover(invoke)
+		if attempt >= policy.MaxAttempts || !retryable(response, err) {
+			return r.checkResponse(response, err)
+		}
+
+		wait := delay
+		if policy.Jitter > 0 {
+			wait += time.Duration((rand.Float64()*2 - 1) * policy.Jitter * float64(delay))
+		}
+
+		reason := responseMessage(response)
+		if err != nil {
+			reason = err.Error()
+		}
+		r.emit(Event{
+			Type:    RPCRetryEvent,
+			Message: fmt.Sprintf("%s attempt %d of %d failed, retrying in %v: %s", name, attempt, policy.MaxAttempts, wait, reason),
+			Payload: RPCInfo{Name: name, Err: err}})
+
+		r.clock.Sleep(wait)
+
+		delay *= 2
+		if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"crypto/x509"
+	"gen-go/apache/aurora"
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/pkg/errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	refusedErr = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	limitErr   = &ThriftLimitError{Limit: "message size", Max: 1024}
+	loopErr    = &RedirectLoopError{Chain: []string{"http://a/api", "http://b/api", "http://a/api"}}
+	appErr     = thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "internal error")
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  *aurora.Response
+		err       error
+		transient bool
+	}{
+		{"no response", nil, nil, false},
+		{"ok", &aurora.Response{ResponseCode: aurora.ResponseCode_OK}, nil, false},
+		{"invalid request", &aurora.Response{ResponseCode: aurora.ResponseCode_INVALID_REQUEST}, nil, false},
+		{"error transient", &aurora.Response{ResponseCode: aurora.ResponseCode_ERROR_TRANSIENT}, nil, true},
+		{"transport timed out", nil, thrift.NewTTransportException(thrift.TIMED_OUT, "i/o timeout"), true},
+		{"transport not open", nil, thrift.NewTTransportException(thrift.NOT_OPEN, "not open"), true},
+		{"connection refused", nil, refusedErr, true},
+		{"wrapped connection refused", nil, errors.Wrap(refusedErr, "Error creating Aurora job"), true},
+		{"bad gateway", nil, errors.New("HTTP Response code: 502"), true},
+		{"service unavailable", nil, errors.New("HTTP Response code: 503"), true},
+		{"gateway timeout", nil, errors.New("HTTP Response code: 504"), true},
+		{"service unavailable transport", nil, thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "HTTP Response code: 503"), true},
+		{"internal server error", nil, errors.New("HTTP Response code: 500"), false},
+		{"unauthorized", nil, errors.New("HTTP Response code: 401"), false},
+		{"thrift limit", nil, limitErr, false},
+		{"redirect loop", nil, loopErr, false},
+		{"application exception", nil, appErr, false},
+		{"closed client", nil, errors.New("Client is closed"), false},
+	}
+
+	for _, test := range tests {
+		if transient := IsTransient(test.response, test.err); transient != test.transient {
+			t.Errorf("%s: IsTransient() = %v, want %v", test.name, transient, test.transient)
+		}
+	}
+}
+
+func TestDiagnoseConnectionError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		problem ConnectionProblem
+	}{
+		{"dns", &net.DNSError{Err: "no such host", Name: "aurora.example.com"}, DNSProblem},
+		{"unknown authority", x509.UnknownAuthorityError{}, TLSProblem},
+		{"protocol", thrift.NewTProtocolException(errors.New("bad version")), ProtocolProblem},
+		{"thrift limit", errors.Wrap(limitErr, "Error getting tasks"), ProtocolProblem},
+		{"redirect loop", loopErr, ProtocolProblem},
+		{"application exception", appErr, SchedulerProblem},
+		{"unauthorized", errors.New("HTTP Response code: 401"), AuthProblem},
+		{"forbidden", errors.New("HTTP Response code: 403"), AuthProblem},
+		{"unauthorized transport", thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "HTTP Response code: 401"), AuthProblem},
+		{"not found", errors.New("HTTP Response code: 404"), ProtocolProblem},
+		{"server error", errors.New("HTTP Response code: 500"), SchedulerProblem},
+		{"connection refused", refusedErr, NetworkProblem},
+		{"transport", thrift.NewTTransportException(thrift.END_OF_FILE, "EOF"), NetworkProblem},
+		{"closed client", errors.New("Client is closed"), UnknownProblem},
+	}
+
+	for _, test := range tests {
+		if problem := diagnoseConnectionError(test.err).Problem; problem != test.problem {
+			t.Errorf("%s: problem = %s, want %s", test.name, problem, test.problem)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	unavailable := errors.New("HTTP Response code: 503")
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		failures []error
+		attempts int
+		waits    []time.Duration
+		fails    bool
+	}{
+		{
+			name:     "no retries",
+			policy:   NoRetries,
+			failures: []error{unavailable},
+			attempts: 1,
+			fails:    true,
+		},
+		{
+			name:     "recovers",
+			policy:   RetryPolicy{MaxAttempts: 4, BaseDelay: time.Second, MaxDelay: 3 * time.Second},
+			failures: []error{unavailable, unavailable, unavailable},
+			attempts: 4,
+			waits:    []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			name:     "gives up",
+			policy:   RetryPolicy{MaxAttempts: 2, BaseDelay: time.Second},
+			failures: []error{unavailable, unavailable, unavailable},
+			attempts: 2,
+			waits:    []time.Duration{time.Second},
+			fails:    true,
+		},
+		{
+			name:     "not transient",
+			policy:   RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second},
+			failures: []error{errors.New("HTTP Response code: 401")},
+			attempts: 1,
+			fails:    true,
+		},
+		{
+			name: "custom retryable",
+			policy: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, Retryable: func(*aurora.Response, error) bool {
+				return false
+			}},
+			failures: []error{unavailable},
+			attempts: 1,
+			fails:    true,
+		},
+	}
+
+	for _, test := range tests {
+		clock := newFakeClock()
+		r := newTestClient(t, clock)
+		r.SetRetryPolicy(test.policy)
+
+		attempts := 0
+		_, err := r.thriftCall("getTasksStatus", func(*connection) (*aurora.Response, error) {
+			attempts++
+			if attempts <= len(test.failures) {
+				return nil, test.failures[attempts-1]
+			}
+			return &aurora.Response{ResponseCode: aurora.ResponseCode_OK}, nil
+		})
+
+		if (err != nil) != test.fails {
+			t.Errorf("%s: err = %v, want failure %v", test.name, err, test.fails)
+		}
+		if attempts != test.attempts {
+			t.Errorf("%s: %d attempts, want %d", test.name, attempts, test.attempts)
+		}
+		if !reflect.DeepEqual(clock.waits, test.waits) {
+			t.Errorf("%s: waited %v, want %v", test.name, clock.waits, test.waits)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"math"
+	"time"
+)
+
+// Peak usage observed for the instances of a job.
+type ObservedUsage struct {
+	CPUs  float64
+	RamMb float64
+}
+
+// Provides the observed usage of a job given its running tasks. Implementations may query
+// Mesos agents, as AgentUsageSource does, or an external metrics system.
+type UsageSource func(key *aurora.JobKey, tasks []*aurora.ScheduledTask) (ObservedUsage, error)
+
+// Recommended change to a single resource of a job.
+type ResourceRecommendation struct {
+	Resource    string
+	Requested   float64
+	Observed    float64
+	Recommended float64
+}
+
+// Relative change suggested by the recommendation, e.g. -40 to reduce by 40%.
+func (r ResourceRecommendation) ChangePercent() float64 {
+	if r.Requested == 0 {
+		return 0
+	}
+	return (r.Recommended - r.Requested) / r.Requested * 100
+}
+
+func (r ResourceRecommendation) String() string {
+	change := r.ChangePercent()
+	switch {
+	case change < 0:
+		return fmt.Sprintf("reduce %s by %.0f%% (%g -> %g)", r.Resource, -change, r.Requested, r.Recommended)
+	case change > 0:
+		return fmt.Sprintf("increase %s by %.0f%% (%g -> %g)", r.Resource, change, r.Requested, r.Recommended)
+	default:
+		return fmt.Sprintf("keep %s at %g", r.Resource, r.Requested)
+	}
+}
+
+// Rightsizing recommendations for a job.
+type RightsizingReport struct {
+	JobKey          *aurora.JobKey
+	Instances       int
+	Recommendations []ResourceRecommendation
+	Err             error
+}
+
+// Compare the resources requested by each job with the usage reported by the source and
+// recommend new values leaving the given headroom, e.g. 0.2 for 20% above observed usage.
+func (r *RealisClient) RightsizingReports(
+	keys []*aurora.JobKey,
+	source UsageSource,
+	headroom float64) ([]RightsizingReport, error) {
+
+	reports := make([]RightsizingReport, 0, len(keys))
+	for _, key := range keys {
+		report := RightsizingReport{JobKey: key}
+
+		tasks, err := r.GetTaskStatus(&aurora.TaskQuery{
+			Role:        key.Role,
+			Environment: key.Environment,
+			JobName:     key.Name,
+			Statuses:    runningStates()})
+		if err != nil {
+			return nil, errors.Wrap(err, "Unable to retrieve running tasks")
+		}
+
+		report.Instances = len(tasks)
+		if len(tasks) == 0 {
+			report.Err = errors.New("No running instances to observe")
+			reports = append(reports, report)
+			continue
+		}
+
+		usage, err := source(key, tasks)
+		if err != nil {
+			report.Err = err
+			reports = append(reports, report)
+			continue
+		}
+
+		cpus, ramMb, _ := taskResources(tasks[0].AssignedTask.Task)
+		report.Recommendations = []ResourceRecommendation{
+			recommend("cpu", cpus, usage.CPUs, headroom, 0.01),
+			recommend("ram", float64(ramMb), usage.RamMb, headroom, 1)}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+func recommend(resource string, requested float64, observed float64, headroom float64, unit float64) ResourceRecommendation {
+	return ResourceRecommendation{
+		Resource:    resource,
+		Requested:   requested,
+		Observed:    observed,
+		Recommended: math.Ceil(observed*(1+headroom)/unit) * unit}
+}
+
+// Usage source sampling the Mesos agents of every instance twice, sampleInterval apart, and
+// returning the peak cpu and memory usage across instances.
+func AgentUsageSource(agentPort int, sampleInterval time.Duration) UsageSource {
+	return func(key *aurora.JobKey, tasks []*aurora.ScheduledTask) (ObservedUsage, error) {
+		first := make(map[string]*TaskUsage)
+		for _, task := range tasks {
+			usage, err := TaskResourceUsage(task, agentPort, sampleInterval)
+			if err != nil {
+				return ObservedUsage{}, err
+			}
+			first[task.AssignedTask.TaskId] = usage
+		}
+
+		time.Sleep(sampleInterval)
+
+		var peak ObservedUsage
+		for _, task := range tasks {
+			usage, err := TaskResourceUsage(task, agentPort, sampleInterval)
+			if err != nil {
+				return ObservedUsage{}, err
+			}
+
+			peak.CPUs = math.Max(peak.CPUs, CPUUsage(first[task.AssignedTask.TaskId], usage))
+			peak.RamMb = math.Max(peak.RamMb, float64(usage.MemRSSBytes)/(1024*1024))
+		}
+
+		return peak, nil
+	}
+}
+
+// Resources requested by a task configuration, preferring the resource set over the
+// deprecated scalar fields.
+func taskResources(config *aurora.TaskConfig) (cpus float64, ramMb int64, diskMb int64) {
+	if config == nil {
+		return 0, 0, 0
+	}
+
+	cpus, ramMb, diskMb = config.NumCpus, config.RamMb, config.DiskMb
+	for resource := range config.Resources {
+		switch {
+		case resource.NumCpus != nil:
+			cpus = *resource.NumCpus
+		case resource.RamMb != nil:
+			ramMb = *resource.RamMb
+		case resource.DiskMb != nil:
+			diskMb = *resource.DiskMb
+		}
+	}
+
+	return cpus, ramMb, diskMb
+}
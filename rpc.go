@@ -0,0 +1,75 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"time"
+)
+
+// Details of a call made to the scheduler, attached as payload to RPC events. Duration,
+// ResponseCode and Err are only set once the call has ended.
+type RPCInfo struct {
+	Name         string
+	Duration     time.Duration
+	ResponseCode aurora.ResponseCode
+	Err          error
+}
+
+type rpcFunc func(conn *connection) (*aurora.Response, error)
+
+// Invoke a scheduler RPC with the client's default credentials.
+func (r *RealisClient) thriftCall(name string, call rpcFunc) (*aurora.Response, error) {
+	return r.withRetries(name, func() (*aurora.Response, error) {
+		return r.rpc(name, nil, call)
+	})
+}
+
+// Invoke a scheduler RPC over a pooled connection, authenticating with credentials instead of
+// the default ones when set. Publishes an event when the call starts and another one when it
+// ends.
+func (r *RealisClient) rpc(name string, credentials *Credentials, call rpcFunc) (response *aurora.Response, err error) {
+	conn, err := r.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { r.release(conn, err != nil) }()
+
+	if credentials != nil {
+		defer conn.authenticate(*credentials)()
+	}
+
+	r.emit(Event{Type: RPCStartEvent, Message: name, Payload: RPCInfo{Name: name}})
+
+	start := r.clock.Now()
+	response, err = call(conn)
+
+	elapsed := r.clock.Now().Sub(start)
+	if err != nil {
+		if limitErr := conn.limits.exceededLimit(); limitErr != nil {
+			err = limitErr
+		} else {
+			err = r.timeoutError(name, elapsed, err)
+		}
+	}
+
+	info := RPCInfo{Name: name, Duration: elapsed, Err: err}
+	if response != nil {
+		info.ResponseCode = response.ResponseCode
+	}
+	r.emit(Event{Type: RPCEndEvent, Message: name, Payload: info})
+
+	return response, err
+}
@@ -0,0 +1,168 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Port on which the Thermos observer serves its HTTP API by default.
+const DefaultObserverPort = 1338
+
+// Returns the disk space in MB used by the sandbox of a running task.
+type SandboxUsageSource func(task *aurora.ScheduledTask) (int64, error)
+
+// Thresholds used to flag jobs in a disk report.
+type DiskThresholds struct {
+	// Requests of this many MB per task or more are flagged. Zero disables the check.
+	LargeRequestMb int64
+
+	// Sandboxes using at least this fraction of their disk request are flagged. Zero disables
+	// the check.
+	NearFullFraction float64
+}
+
+// Disk request and sandbox usage of a job's running tasks.
+type DiskAdvice struct {
+	JobKey      *aurora.JobKey
+	RequestedMb int64
+	Instances   int
+
+	// Largest sandbox among the job's tasks, zero when usage wasn't sampled.
+	MaxUsedMb int64
+
+	LargeRequest bool
+	NearFull     bool
+}
+
+// Fraction of the disk request used by the largest sandbox.
+func (a DiskAdvice) UsedFraction() float64 {
+	if a.RequestedMb <= 0 {
+		return 0
+	}
+	return float64(a.MaxUsedMb) / float64(a.RequestedMb)
+}
+
+// List the jobs of a role that request a lot of disk or whose sandboxes are close to full.
+// Sandbox usage is only sampled when a usage source is given; tasks whose usage can't be read
+// are skipped. Jobs are ordered by how full their largest sandbox is, then by request size.
+func (r *RealisClient) DiskReport(role string, thresholds DiskThresholds, usage SandboxUsageSource) ([]DiskAdvice, error) {
+	tasks, err := r.GetTaskStatus(&aurora.TaskQuery{
+		Role:     role,
+		Statuses: runningStates()})
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to retrieve running tasks")
+	}
+
+	jobs := make(map[string]*DiskAdvice)
+	var order []string
+	for _, task := range tasks {
+		config := task.AssignedTask.Task
+		key := config.Job
+		id := key.Role + "/" + key.Environment + "/" + key.Name
+
+		advice, ok := jobs[id]
+		if !ok {
+			_, _, diskMb := taskResources(config)
+			advice = &DiskAdvice{JobKey: key, RequestedMb: diskMb}
+			jobs[id] = advice
+			order = append(order, id)
+		}
+		advice.Instances++
+
+		if usage == nil {
+			continue
+		}
+
+		usedMb, err := usage(task)
+		if err != nil {
+			continue
+		}
+		if usedMb > advice.MaxUsedMb {
+			advice.MaxUsedMb = usedMb
+		}
+	}
+
+	var report []DiskAdvice
+	for _, id := range order {
+		advice := jobs[id]
+		advice.LargeRequest = thresholds.LargeRequestMb > 0 && advice.RequestedMb >= thresholds.LargeRequestMb
+		advice.NearFull = thresholds.NearFullFraction > 0 && advice.UsedFraction() >= thresholds.NearFullFraction
+
+		if advice.LargeRequest || advice.NearFull {
+			report = append(report, *advice)
+		}
+	}
+
+	sort.SliceStable(report, func(i, j int) bool {
+		if report[i].UsedFraction() != report[j].UsedFraction() {
+			return report[i].UsedFraction() > report[j].UsedFraction()
+		}
+		return report[i].RequestedMb > report[j].RequestedMb
+	})
+
+	return report, nil
+}
+
+type observerTask struct {
+	ResourceConsumption struct {
+		Disk int64 `json:"disk"`
+	} `json:"resource_consumption"`
+}
+
+// Read sandbox usage from the Thermos observer running next to each task.
+func ObserverSandboxUsage(observerPort int, timeout time.Duration) SandboxUsageSource {
+	client := &http.Client{Timeout: timeout}
+
+	return func(task *aurora.ScheduledTask) (int64, error) {
+		if task.AssignedTask == nil || task.AssignedTask.SlaveHost == "" {
+			return 0, errors.New("Task is not assigned to an agent")
+		}
+
+		taskId := task.AssignedTask.TaskId
+		host := task.AssignedTask.SlaveHost
+		endpoint := "http://" + net.JoinHostPort(host, strconv.Itoa(observerPort)) +
+			"/j/task?task_id=" + url.QueryEscape(taskId)
+
+		resp, err := client.Get(endpoint)
+		if err != nil {
+			return 0, errors.Wrapf(err, "Error querying observer on %s", host)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return 0, errors.Errorf("Observer on %s returned %s", host, resp.Status)
+		}
+
+		var tasks map[string]observerTask
+		if err := decodeJSON(resp.Body, &tasks); err != nil {
+			return 0, errors.Wrapf(err, "Error decoding task %s from observer on %s", taskId, host)
+		}
+
+		observed, ok := tasks[taskId]
+		if !ok {
+			return 0, errors.Errorf("Observer on %s doesn't know task %s", host, taskId)
+		}
+
+		return observed.ResourceConsumption.Disk / (1024 * 1024), nil
+	}
+}
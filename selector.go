@@ -0,0 +1,77 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"strings"
+)
+
+// Prefix Aurora may add to metadata keys when passing them to Mesos as labels.
+const metadataPrefix = "org.apache.aurora.metadata."
+
+// Predicate used to select jobs.
+type JobSelector func(config *aurora.JobConfiguration) bool
+
+// Select jobs carrying the metadata label key=value.
+func LabelSelector(key string, value string) JobSelector {
+	key = strings.TrimPrefix(key, metadataPrefix)
+
+	return func(config *aurora.JobConfiguration) bool {
+		if config.TaskConfig == nil {
+			return false
+		}
+
+		for metadata := range config.TaskConfig.Metadata {
+			if strings.TrimPrefix(metadata.Key, metadataPrefix) == key && metadata.Value == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Select jobs running in an environment.
+func EnvironmentSelector(env string) JobSelector {
+	return func(config *aurora.JobConfiguration) bool {
+		return config.Key != nil && config.Key.Environment == env
+	}
+}
+
+// Retrieve the jobs of a role, or of the whole cluster when role is empty, matching all the
+// selectors. Used for operations such as restarting all jobs labeled team=payments.
+func (r *RealisClient) SelectJobs(role string, selectors ...JobSelector) ([]*aurora.JobConfiguration, error) {
+	configs, err := r.GetJobs(role)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []*aurora.JobConfiguration
+	for _, config := range configs {
+		matches := true
+		for _, selector := range selectors {
+			if !selector(config) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			selected = append(selected, config)
+		}
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,107 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"encoding/json"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"github.com/samuel/go-zookeeper/zk"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Root ZooKeeper path under which the Thermos announcer registers jobs by default.
+const DefaultServersetRoot = "/aurora"
+
+// Host and port pair announced by an instance.
+type ServiceEndpoint struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+// Member of a serverset as written by the Thermos announcer.
+type ServiceInstance struct {
+	ServiceEndpoint     ServiceEndpoint            `json:"serviceEndpoint"`
+	AdditionalEndpoints map[string]ServiceEndpoint `json:"additionalEndpoints"`
+	Status              string                     `json:"status"`
+	Shard               *int32                     `json:"shard,omitempty"`
+}
+
+// Path of the serverset a job announces itself to, e.g. /aurora/role/env/name.
+func ServersetPath(root string, key *aurora.JobKey) string {
+	return strings.TrimRight(root, "/") + "/" + key.Role + "/" + key.Environment + "/" + key.Name
+}
+
+// List the live endpoints announced under a serverset path, ordered by shard. Lets deploy
+// tooling verify that instances registered themselves after an update.
+func ReadServerset(zkServers []string, path string, timeout time.Duration) ([]ServiceInstance, error) {
+	conn, err := connectZooKeeper(zkServers, timeout, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	members, _, err := conn.Children(path)
+	if err == zk.ErrNoNode {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "Error listing serverset %s", path)
+	}
+
+	var instances []ServiceInstance
+	for _, member := range members {
+		if !strings.HasPrefix(member, "member_") {
+			continue
+		}
+
+		data, _, err := conn.Get(path + "/" + member)
+		if err == zk.ErrNoNode {
+			// Instance went away while the serverset was being read.
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "Error reading serverset member %s", member)
+		}
+
+		var instance ServiceInstance
+		if err := json.Unmarshal(data, &instance); err != nil {
+			return nil, errors.Wrapf(err, "Error parsing serverset member %s", member)
+		}
+
+		if instance.Status == "ALIVE" {
+			instances = append(instances, instance)
+		}
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		if instances[i].Shard == nil || instances[j].Shard == nil {
+			return instances[j].Shard == nil && instances[i].Shard != nil
+		}
+		return *instances[i].Shard < *instances[j].Shard
+	})
+
+	return instances, nil
+}
+
+// Open a ZooKeeper session without the library's per-connection info logging. callback, when
+// not nil, receives the session's events and must not block.
+func connectZooKeeper(zkServers []string, timeout time.Duration, callback zk.EventCallback) (*zk.Conn, error) {
+	conn, _, err := zk.Connect(zkServers, timeout, zk.WithLogInfo(false), zk.WithEventCallback(callback))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error connecting to ZooKeeper")
+	}
+	return conn, nil
+}
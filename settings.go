@@ -0,0 +1,254 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prefix of the environment variables read by a ConfigLoader by default.
+const DefaultEnvPrefix = "GOREALIS_"
+
+// Client settings that can be given in a file, the environment or code. Zero values are unset
+// and leave the value of the lower layer in place.
+type Settings struct {
+	URL string `json:"url"`
+
+	// Schedulers to fail over between, replaces URL when set. A URL set in a higher layer
+	// replaces the endpoints of the lower ones.
+	Endpoints []string `json:"endpoints"`
+	APIPath   string   `json:"api_path"`
+
+	Username string `json:"username"`
+	Password string `json:"password"`
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+
+	// Given as a duration string such as "30s" in files and the environment.
+	Timeout          time.Duration `json:"-"`
+	MaxMessageSize   int64         `json:"max_message_size"`
+	MaxContainerSize int           `json:"max_container_size"`
+}
+
+// Merge the set fields of other over s.
+func (s *Settings) merge(other Settings) {
+	if other.URL != "" {
+		s.URL = other.URL
+		s.Endpoints = nil
+	}
+	if len(other.Endpoints) > 0 {
+		s.Endpoints = other.Endpoints
+	}
+	if other.APIPath != "" {
+		s.APIPath = other.APIPath
+	}
+	if other.Username != "" {
+		s.Username = other.Username
+	}
+	if other.Password != "" {
+		s.Password = other.Password
+	}
+	if other.CertFile != "" {
+		s.CertFile = other.CertFile
+	}
+	if other.KeyFile != "" {
+		s.KeyFile = other.KeyFile
+	}
+	if other.Timeout != 0 {
+		s.Timeout = other.Timeout
+	}
+	if other.MaxMessageSize != 0 {
+		s.MaxMessageSize = other.MaxMessageSize
+	}
+	if other.MaxContainerSize != 0 {
+		s.MaxContainerSize = other.MaxContainerSize
+	}
+}
+
+// Builds a configuration from layered settings: defaults, then a JSON file, then environment
+// variables, then settings given in code, each layer overriding the ones before it.
+type ConfigLoader struct {
+	file      string
+	envPrefix string
+	overrides Settings
+}
+
+func NewConfigLoader() *ConfigLoader {
+	return &ConfigLoader{envPrefix: DefaultEnvPrefix}
+}
+
+// Read settings from a JSON file with the fields of Settings, e.g. {"url": "...", "timeout": "30s"}.
+func (l *ConfigLoader) File(path string) *ConfigLoader {
+	l.file = path
+	return l
+}
+
+// Read environment variables with the given prefix, e.g. GOREALIS_URL or GOREALIS_TIMEOUT.
+// An empty prefix disables the environment layer.
+func (l *ConfigLoader) EnvPrefix(prefix string) *ConfigLoader {
+	l.envPrefix = prefix
+	return l
+}
+
+// Settings taking precedence over every other layer.
+func (l *ConfigLoader) Override(settings Settings) *ConfigLoader {
+	l.overrides.merge(settings)
+	return l
+}
+
+// Merge all layers into the effective settings.
+func (l *ConfigLoader) Settings() (Settings, error) {
+	settings := Settings{APIPath: defaultAPIPath, Timeout: 10 * time.Second}
+
+	if l.file != "" {
+		fileSettings, err := readSettingsFile(l.file)
+		if err != nil {
+			return Settings{}, err
+		}
+		settings.merge(fileSettings)
+	}
+
+	if l.envPrefix != "" {
+		envSettings, err := readSettingsEnv(l.envPrefix)
+		if err != nil {
+			return Settings{}, err
+		}
+		settings.merge(envSettings)
+	}
+
+	settings.merge(l.overrides)
+
+	apiPath, err := normalizeAPIPath(settings.APIPath)
+	if err != nil {
+		return Settings{}, err
+	}
+	settings.APIPath = apiPath
+
+	return settings, nil
+}
+
+// Merge all layers and build the configuration they describe.
+func (l *ConfigLoader) Resolve() (RealisConfig, error) {
+	settings, err := l.Settings()
+	if err != nil {
+		return RealisConfig{}, err
+	}
+
+	var config RealisConfig
+	switch {
+	case len(settings.Endpoints) > 0:
+		config, err = NewConfigWithEndpointsAndAPIPath(settings.Endpoints, settings.APIPath)
+	case settings.URL != "":
+		config, err = NewDefaultConfigWithAPIPath(settings.URL, settings.APIPath)
+	default:
+		return RealisConfig{}, errors.New("No scheduler URL or endpoints configured")
+	}
+	if err != nil {
+		return RealisConfig{}, err
+	}
+
+	config.httpClient.Timeout = settings.Timeout
+
+	if settings.Username != "" {
+		if err := AddBasicAuth(&config, settings.Username, settings.Password); err != nil {
+			return RealisConfig{}, err
+		}
+	}
+
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		if err := WithClientCertificate(&config, settings.CertFile, settings.KeyFile); err != nil {
+			return RealisConfig{}, err
+		}
+	}
+
+	if settings.MaxMessageSize > 0 || settings.MaxContainerSize > 0 {
+		limits := ThriftLimits{MaxMessageSize: settings.MaxMessageSize, MaxContainerSize: settings.MaxContainerSize}
+		if err := WithThriftLimits(&config, limits); err != nil {
+			return RealisConfig{}, err
+		}
+	}
+
+	return config, nil
+}
+
+func readSettingsFile(path string) (Settings, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Settings{}, errors.Wrap(err, "Error reading configuration file")
+	}
+
+	var file struct {
+		Settings
+		Timeout string `json:"timeout"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return Settings{}, errors.Wrapf(err, "Error parsing configuration file %s", path)
+	}
+
+	settings := file.Settings
+	if file.Timeout != "" {
+		if settings.Timeout, err = time.ParseDuration(file.Timeout); err != nil {
+			return Settings{}, errors.Wrapf(err, "Invalid timeout in configuration file %s", path)
+		}
+	}
+
+	return settings, nil
+}
+
+func readSettingsEnv(prefix string) (Settings, error) {
+	var settings Settings
+	var err error
+
+	settings.URL = os.Getenv(prefix + "URL")
+	settings.APIPath = os.Getenv(prefix + "API_PATH")
+	settings.Username = os.Getenv(prefix + "USERNAME")
+	settings.Password = os.Getenv(prefix + "PASSWORD")
+	settings.CertFile = os.Getenv(prefix + "CERT_FILE")
+	settings.KeyFile = os.Getenv(prefix + "KEY_FILE")
+
+	if endpoints := os.Getenv(prefix + "ENDPOINTS"); endpoints != "" {
+		for _, endpoint := range strings.Split(endpoints, ",") {
+			if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+				settings.Endpoints = append(settings.Endpoints, endpoint)
+			}
+		}
+	}
+
+	if timeout := os.Getenv(prefix + "TIMEOUT"); timeout != "" {
+		if settings.Timeout, err = time.ParseDuration(timeout); err != nil {
+			return Settings{}, errors.Wrapf(err, "Invalid %sTIMEOUT", prefix)
+		}
+	}
+
+	if size := os.Getenv(prefix + "MAX_MESSAGE_SIZE"); size != "" {
+		if settings.MaxMessageSize, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return Settings{}, errors.Wrapf(err, "Invalid %sMAX_MESSAGE_SIZE", prefix)
+		}
+	}
+
+	if size := os.Getenv(prefix + "MAX_CONTAINER_SIZE"); size != "" {
+		if settings.MaxContainerSize, err = strconv.Atoi(size); err != nil {
+			return Settings{}, errors.Wrapf(err, "Invalid %sMAX_CONTAINER_SIZE", prefix)
+		}
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,120 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfigLoaderPrecedence(t *testing.T) {
+	const prefix = "GOREALIS_TEST_"
+
+	tests := []struct {
+		name     string
+		file     string
+		env      map[string]string
+		override Settings
+		want     Settings
+		fails    bool
+	}{
+		{
+			name: "defaults",
+			want: Settings{APIPath: "/api", Timeout: 10 * time.Second},
+		},
+		{
+			name: "file",
+			file: `{"url": "http://file:8081", "timeout": "30s", "username": "aurora"}`,
+			want: Settings{URL: "http://file:8081", APIPath: "/api", Timeout: 30 * time.Second, Username: "aurora"},
+		},
+		{
+			name: "environment over file",
+			file: `{"url": "http://file:8081", "timeout": "30s"}`,
+			env:  map[string]string{"URL": "http://env:8081", "PASSWORD": "secret"},
+			want: Settings{URL: "http://env:8081", APIPath: "/api", Timeout: 30 * time.Second, Password: "secret"},
+		},
+		{
+			name:     "override over environment",
+			env:      map[string]string{"URL": "http://env:8081", "TIMEOUT": "1m"},
+			override: Settings{URL: "http://code:8081"},
+			want:     Settings{URL: "http://code:8081", APIPath: "/api", Timeout: time.Minute},
+		},
+		{
+			name: "url over lower endpoints",
+			file: `{"endpoints": ["http://a:8081", "http://b:8081"]}`,
+			env:  map[string]string{"URL": "http://env:8081"},
+			want: Settings{URL: "http://env:8081", APIPath: "/api", Timeout: 10 * time.Second},
+		},
+		{
+			name: "endpoints over url of the same layer",
+			env:  map[string]string{"URL": "http://env:8081", "ENDPOINTS": "http://a:8081, http://b:8081"},
+			want: Settings{
+				URL:       "http://env:8081",
+				Endpoints: []string{"http://a:8081", "http://b:8081"},
+				APIPath:   "/api",
+				Timeout:   10 * time.Second},
+		},
+		{
+			name:     "api path normalized",
+			file:     `{"api_path": "/file/api"}`,
+			override: Settings{APIPath: "aurora/api/"},
+			want:     Settings{APIPath: "/aurora/api", Timeout: 10 * time.Second},
+		},
+		{
+			name:  "invalid file",
+			file:  `{"url": `,
+			fails: true,
+		},
+		{
+			name:  "invalid timeout",
+			env:   map[string]string{"TIMEOUT": "soon"},
+			fails: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loader := NewConfigLoader().EnvPrefix(prefix).Override(test.override)
+
+			if test.file != "" {
+				path := filepath.Join(t.TempDir(), "gorealis.json")
+				if err := ioutil.WriteFile(path, []byte(test.file), 0600); err != nil {
+					t.Fatal(err)
+				}
+				loader.File(path)
+			}
+			for name, value := range test.env {
+				t.Setenv(prefix+name, value)
+			}
+
+			settings, err := loader.Settings()
+			if test.fails {
+				if err == nil {
+					t.Errorf("loaded %+v, want an error", settings)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(settings, test.want) {
+				t.Errorf("loaded %+v, want %+v", settings, test.want)
+			}
+		})
+	}
+}
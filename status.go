@@ -0,0 +1,166 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import "gen-go/apache/aurora"
+
+// Whether a task in this status is active, i.e. pending, assigned or running on an agent.
+func IsActive(status aurora.ScheduleStatus) bool {
+	return aurora.ACTIVE_STATES[status]
+}
+
+// Whether a task in this status is live on an agent.
+func IsLive(status aurora.ScheduleStatus) bool {
+	return aurora.LIVE_STATES[status]
+}
+
+// Whether a task in this status has ended and will not transition again.
+func IsTerminal(status aurora.ScheduleStatus) bool {
+	return aurora.TERMINAL_STATES[status]
+}
+
+// Statuses of tasks that are pending, assigned or running. The set is a copy of the one defined
+// by the Aurora API and may be modified by the caller.
+func ActiveStates() map[aurora.ScheduleStatus]bool {
+	return copyStates(aurora.ACTIVE_STATES)
+}
+
+// Statuses of tasks that are live on an agent.
+func LiveStates() map[aurora.ScheduleStatus]bool {
+	return copyStates(aurora.LIVE_STATES)
+}
+
+// Statuses of tasks that have ended.
+func TerminalStates() map[aurora.ScheduleStatus]bool {
+	return copyStates(aurora.TERMINAL_STATES)
+}
+
+func copyStates(states map[aurora.ScheduleStatus]bool) map[aurora.ScheduleStatus]bool {
+	statesCopy := make(map[aurora.ScheduleStatus]bool, len(states))
+	for status, ok := range states {
+		statesCopy[status] = ok
+	}
+	return statesCopy
+}
+
+// Whether an update in this status has finished and will not transition again.
+func IsUpdateTerminal(status aurora.JobUpdateStatus) bool {
+	return !aurora.ACTIVE_JOB_UPDATE_STATES[status]
+}
+
+// Whether an update in this status is blocked until it receives a heartbeat through
+// PulseJobUpdate.
+func IsUpdateAwaitingPulse(status aurora.JobUpdateStatus) bool {
+	return status == aurora.JobUpdateStatus_ROLL_FORWARD_AWAITING_PULSE ||
+		status == aurora.JobUpdateStatus_ROLL_BACK_AWAITING_PULSE
+}
+
+// Whether an update in this status was paused by a user and waits to be resumed.
+func IsUpdatePaused(status aurora.JobUpdateStatus) bool {
+	return status == aurora.JobUpdateStatus_ROLL_FORWARD_PAUSED ||
+		status == aurora.JobUpdateStatus_ROLL_BACK_PAUSED
+}
+
+// Whether an update in this status is moving, or will move once resumed or pulsed, back to the
+// previous configuration.
+func IsUpdateRollingBack(status aurora.JobUpdateStatus) bool {
+	return status == aurora.JobUpdateStatus_ROLLING_BACK ||
+		status == aurora.JobUpdateStatus_ROLL_BACK_PAUSED ||
+		status == aurora.JobUpdateStatus_ROLL_BACK_AWAITING_PULSE
+}
+
+// Estimate in percent of how far an update is through its current direction. Rolling forward
+// counts the instances updated out of all instances touched by the update, rolling back counts
+// the instances rolled back out of those that had been updated. Updates limited to some
+// instances only count those. Terminal updates are at 100 whatever their outcome, details
+// without a state are at 0.
+func UpdateProgress(details *aurora.JobUpdateDetails) float64 {
+	if details == nil || details.Update == nil || details.Update.Summary == nil || details.Update.Summary.State == nil {
+		return 0
+	}
+
+	status := details.Update.Summary.State.Status
+	if IsUpdateTerminal(status) {
+		return 100
+	}
+
+	// Latest event of every instance touched by the update.
+	latest := make(map[int32]*aurora.JobInstanceUpdateEvent)
+	for _, event := range details.InstanceEvents {
+		if last, ok := latest[event.InstanceId]; !ok || event.TimestampMs >= last.TimestampMs {
+			latest[event.InstanceId] = event
+		}
+	}
+
+	var done, total int
+	if IsUpdateRollingBack(status) {
+		total = len(latest)
+		for _, event := range latest {
+			if event.Action == aurora.JobUpdateAction_INSTANCE_ROLLED_BACK {
+				done++
+			}
+		}
+	} else {
+		instances := updateInstances(details.Update.Instructions)
+		total = len(instances)
+		for id, event := range latest {
+			if instances[id] && event.Action == aurora.JobUpdateAction_INSTANCE_UPDATED {
+				done++
+			}
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(done) / float64(total)
+}
+
+// Instances in either the initial or the desired state of an update, limited to the ones the
+// update's settings restrict it to.
+func updateInstances(instructions *aurora.JobUpdateInstructions) map[int32]bool {
+	instances := make(map[int32]bool)
+	if instructions == nil {
+		return instances
+	}
+
+	addRanges := func(ranges map[*aurora.Range]bool) {
+		for r := range ranges {
+			for id := r.First; id <= r.Last; id++ {
+				instances[id] = true
+			}
+		}
+	}
+
+	for initial := range instructions.InitialState {
+		addRanges(initial.Instances)
+	}
+	if instructions.DesiredState != nil {
+		addRanges(instructions.DesiredState.Instances)
+	}
+
+	if instructions.Settings != nil && len(instructions.Settings.UpdateOnlyTheseInstances) > 0 {
+		only := instances
+		instances = make(map[int32]bool)
+		addRanges(instructions.Settings.UpdateOnlyTheseInstances)
+		for id := range instances {
+			if !only[id] {
+				delete(instances, id)
+			}
+		}
+	}
+
+	return instances
+}
@@ -0,0 +1,82 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"testing"
+)
+
+func TestUpdateProgress(t *testing.T) {
+	instances := func(first, last int32) map[*aurora.Range]bool {
+		return map[*aurora.Range]bool{{First: first, Last: last}: true}
+	}
+	event := func(instance int32, action aurora.JobUpdateAction, timestamp int64) *aurora.JobInstanceUpdateEvent {
+		return &aurora.JobInstanceUpdateEvent{InstanceId: instance, Action: action, TimestampMs: timestamp}
+	}
+	update := func(status aurora.JobUpdateStatus, only map[*aurora.Range]bool, events ...*aurora.JobInstanceUpdateEvent) *aurora.JobUpdateDetails {
+		return &aurora.JobUpdateDetails{
+			Update: &aurora.JobUpdate{
+				Summary: &aurora.JobUpdateSummary{State: &aurora.JobUpdateState{Status: status}},
+				Instructions: &aurora.JobUpdateInstructions{
+					InitialState: map[*aurora.InstanceTaskConfig]bool{{Instances: instances(0, 3)}: true},
+					DesiredState: &aurora.InstanceTaskConfig{Instances: instances(0, 3)},
+					Settings:     &aurora.JobUpdateSettings{UpdateOnlyTheseInstances: only}}},
+			InstanceEvents: events}
+	}
+
+	const (
+		updated    = aurora.JobUpdateAction_INSTANCE_UPDATED
+		updating   = aurora.JobUpdateAction_INSTANCE_UPDATING
+		rolledBack = aurora.JobUpdateAction_INSTANCE_ROLLED_BACK
+	)
+
+	tests := []struct {
+		name     string
+		details  *aurora.JobUpdateDetails
+		progress float64
+	}{
+		{"no details", nil, 0},
+		{"no update", &aurora.JobUpdateDetails{}, 0},
+		{"no summary", &aurora.JobUpdateDetails{Update: &aurora.JobUpdate{}}, 0},
+		{"no state", &aurora.JobUpdateDetails{Update: &aurora.JobUpdate{Summary: &aurora.JobUpdateSummary{}}}, 0},
+		{"rolled forward", update(aurora.JobUpdateStatus_ROLLED_FORWARD, nil), 100},
+		{"aborted", update(aurora.JobUpdateStatus_ABORTED, nil, event(0, updating, 1)), 100},
+		{"no instructions", &aurora.JobUpdateDetails{Update: &aurora.JobUpdate{
+			Summary: &aurora.JobUpdateSummary{State: &aurora.JobUpdateState{Status: aurora.JobUpdateStatus_ROLLING_FORWARD}}}}, 0},
+		{"starting", update(aurora.JobUpdateStatus_ROLLING_FORWARD, nil), 0},
+		{"halfway", update(aurora.JobUpdateStatus_ROLLING_FORWARD, nil,
+			event(0, updating, 1), event(0, updated, 2),
+			event(1, updating, 3), event(1, updated, 4),
+			event(2, updating, 5)), 50},
+		{"latest event wins", update(aurora.JobUpdateStatus_ROLL_FORWARD_PAUSED, nil,
+			event(0, updated, 2), event(0, updating, 3), event(1, updated, 1)), 25},
+		{"only these instances", update(aurora.JobUpdateStatus_ROLLING_FORWARD, instances(0, 1),
+			event(0, updated, 1), event(1, updating, 2), event(3, updated, 3)), 50},
+		{"only these instances done", update(aurora.JobUpdateStatus_ROLL_FORWARD_AWAITING_PULSE, instances(2, 3),
+			event(2, updated, 1), event(3, updated, 2)), 100},
+		{"rolling back", update(aurora.JobUpdateStatus_ROLLING_BACK, nil,
+			event(0, updated, 1), event(0, rolledBack, 5),
+			event(1, updated, 2), event(2, updated, 3), event(3, updated, 4)), 25},
+		{"roll back paused", update(aurora.JobUpdateStatus_ROLL_BACK_PAUSED, nil,
+			event(0, rolledBack, 5), event(1, rolledBack, 6)), 100},
+	}
+
+	for _, test := range tests {
+		if progress := UpdateProgress(test.details); progress != test.progress {
+			t.Errorf("%s: progress = %v, want %v", test.name, progress, test.progress)
+		}
+	}
+}
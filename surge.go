@@ -0,0 +1,187 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+// Restart a job without dipping below its current capacity. surgeCount extra instances are added
+// first and, once they are running, the original instances are restarted surgeCount at a time,
+// each batch having to be running again before the next one starts. The extra instances are
+// removed at the end. If a step fails or times out the surge instances are left in place so the
+// job keeps its capacity, and the error is returned.
+func (r *RealisClient) RestartWithSurge(key *aurora.JobKey, surgeCount int32, timeout time.Duration) error {
+	if surgeCount <= 0 {
+		return errors.New("Surge count must be greater than 0")
+	}
+
+	op := &Operation{Name: "RestartJob", JobKey: key}
+	if err := r.authorize(op); err != nil {
+		return err
+	}
+
+	// Each step acts on instances read from the leader at the start.
+	fence := r.Fence()
+	deadline := r.clock.Now().Add(timeout)
+
+	original, err := runningTaskIds(r, key)
+	if err != nil {
+		return err
+	}
+	if len(original) == 0 {
+		return errors.New("No tasks in the RUNNING state.")
+	}
+
+	instanceIds := make(map[int32]bool)
+	for id := range original {
+		instanceIds[id] = true
+	}
+
+	if err := fence.Check(); err != nil {
+		return err
+	}
+
+	_, err = r.AddInstances(&aurora.InstanceKey{JobKey: key, InstanceId: lowestInstanceId(instanceIds)}, surgeCount)
+	if err != nil {
+		return errors.Wrap(err, "Unable to add surge instances")
+	}
+
+	if err := r.awaitRunningCount(key, len(original)+int(surgeCount), deadline); err != nil {
+		return errors.Wrap(err, "Surge instances did not come up")
+	}
+
+	ids := make([]int32, 0, len(original))
+	for id := range original {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for start := 0; start < len(ids); start += int(surgeCount) {
+		end := start + int(surgeCount)
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		batch := make(map[int32]bool)
+		for _, id := range ids[start:end] {
+			batch[id] = true
+		}
+
+		if err := fence.Check(); err != nil {
+			return err
+		}
+
+		response, err := r.thriftCallAs(op, "RestartShards", func(conn *connection) (*aurora.Response, error) {
+			return conn.client.RestartShards(key, batch)
+		})
+		if err != nil {
+			return errors.Wrap(err, "Error sending Restart command to Aurora Scheduler.")
+		}
+		if isUpdateInProgress(response) {
+			return r.updateInProgressError(key, response)
+		}
+		if response.ResponseCode != aurora.ResponseCode_OK {
+			return errors.Errorf("Restart rejected by Aurora Scheduler: %s", responseMessage(response))
+		}
+
+		if err := r.awaitRestarted(key, batch, original, deadline); err != nil {
+			return err
+		}
+	}
+
+	if err := fence.Check(); err != nil {
+		return err
+	}
+
+	if _, err := r.RemoveInstances(key, surgeCount); err != nil {
+		return errors.Wrap(err, "Unable to remove surge instances")
+	}
+
+	return nil
+}
+
+// Task ids of the running instances of a job.
+func runningTaskIds(client Realis, key *aurora.JobKey) (map[int32]string, error) {
+	tasks, err := client.GetTasksWithoutConfigs(&aurora.TaskQuery{
+		Role:        key.Role,
+		Environment: key.Environment,
+		JobName:     key.Name,
+		Statuses:    runningStates()})
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to retrieve running tasks")
+	}
+
+	running := make(map[int32]string)
+	for _, task := range tasks {
+		running[task.AssignedTask.InstanceId] = task.AssignedTask.TaskId
+	}
+	return running, nil
+}
+
+// Wait for at least count instances of the job to be running.
+func (r *RealisClient) awaitRunningCount(key *aurora.JobKey, count int, deadline time.Time) error {
+	poll := r.newPoller()
+
+	for {
+		running, err := runningTaskIds(r, key)
+		if err != nil {
+			return err
+		}
+
+		if len(running) >= count {
+			return nil
+		}
+
+		wait := poll.next(len(running))
+		if r.clock.Now().Add(wait).After(deadline) {
+			return errors.Errorf("Timed out with %d of %d instances running", len(running), count)
+		}
+		r.clock.Sleep(wait)
+	}
+}
+
+// Wait for every instance of the batch to run a task other than the one it ran before, without
+// limit when deadline is zero.
+func (r *RealisClient) awaitRestarted(key *aurora.JobKey, batch map[int32]bool, before map[int32]string, deadline time.Time) error {
+	poll := r.newPoller()
+
+	for {
+		running, err := runningTaskIds(r, key)
+		if err != nil {
+			return err
+		}
+
+		restarted := 0
+		for id := range batch {
+			if taskId, ok := running[id]; ok && taskId != before[id] {
+				restarted++
+			}
+		}
+
+		if restarted == len(batch) {
+			return nil
+		}
+
+		wait := poll.next(restarted)
+		if !deadline.IsZero() && r.clock.Now().Add(wait).After(deadline) {
+			return errors.Errorf("Timed out with %d of %d restarted instances running", restarted, len(batch))
+		}
+		r.clock.Sleep(wait)
+	}
+}
@@ -0,0 +1,136 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"time"
+)
+
+// Wrapper around aurora.ScheduledTask exposing its fields with Go types.
+type Task struct {
+	task *aurora.ScheduledTask
+}
+
+// Transition of a task to a new status.
+type TaskEvent struct {
+	Time      time.Time
+	Status    aurora.ScheduleStatus
+	Message   string
+	Scheduler string
+}
+
+func NewTask(task *aurora.ScheduledTask) *Task {
+	return &Task{task: task}
+}
+
+// Wrap a list of tasks, e.g. as returned by GetTaskStatus.
+func NewTasks(tasks []*aurora.ScheduledTask) []*Task {
+	wrapped := make([]*Task, 0, len(tasks))
+	for _, task := range tasks {
+		wrapped = append(wrapped, NewTask(task))
+	}
+	return wrapped
+}
+
+// Underlying thrift structure.
+func (t *Task) ScheduledTask() *aurora.ScheduledTask {
+	return t.task
+}
+
+func (t *Task) ID() string {
+	return t.assigned().TaskId
+}
+
+func (t *Task) InstanceID() int32 {
+	return t.assigned().InstanceId
+}
+
+// Key of the job the task belongs to, nil if the task was fetched without its configuration.
+func (t *Task) JobKey() *aurora.JobKey {
+	if config := t.Config(); config != nil {
+		return config.Job
+	}
+	return nil
+}
+
+// Configuration of the task, nil if the task was fetched without it.
+func (t *Task) Config() *aurora.TaskConfig {
+	return t.assigned().Task
+}
+
+// Agent the task was assigned to, empty while the task is pending.
+func (t *Task) Host() string {
+	return t.assigned().SlaveHost
+}
+
+func (t *Task) AgentID() string {
+	return t.assigned().SlaveId
+}
+
+func (t *Task) Status() aurora.ScheduleStatus {
+	return t.task.Status
+}
+
+func (t *Task) FailureCount() int32 {
+	return t.task.FailureCount
+}
+
+// Ports assigned to the task, keyed by port name.
+func (t *Task) Ports() map[string]int32 {
+	ports := make(map[string]int32, len(t.assigned().AssignedPorts))
+	for name, port := range t.assigned().AssignedPorts {
+		ports[name] = port
+	}
+	return ports
+}
+
+// Time at which the task was launched on its agent, zero if it never was.
+func (t *Task) LaunchedAt() time.Time {
+	for _, event := range t.task.TaskEvents {
+		if event.Status == aurora.ScheduleStatus_ASSIGNED {
+			return msToTime(event.Timestamp)
+		}
+	}
+	return time.Time{}
+}
+
+// Status transitions of the task, oldest first.
+func (t *Task) Events() []TaskEvent {
+	events := make([]TaskEvent, 0, len(t.task.TaskEvents))
+	for _, event := range t.task.TaskEvents {
+		taskEvent := TaskEvent{Time: msToTime(event.Timestamp), Status: event.Status}
+		if event.Message != nil {
+			taskEvent.Message = *event.Message
+		}
+		if event.Scheduler != nil {
+			taskEvent.Scheduler = *event.Scheduler
+		}
+		events = append(events, taskEvent)
+	}
+	return events
+}
+
+func (t *Task) assigned() *aurora.AssignedTask {
+	if t.task.AssignedTask == nil {
+		return &aurora.AssignedTask{}
+	}
+	return t.task.AssignedTask
+}
+
+// Convert a thrift timestamp in milliseconds since the epoch.
+func msToTime(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
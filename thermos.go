@@ -0,0 +1,305 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"encoding/json"
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"strconv"
+	"time"
+)
+
+// Builder for the Thermos executor payload. It starts from a payload serialized by the Aurora
+// client, such as examples/thermos_payload.json, and overrides sections of it.
+type ThermosExecutor struct {
+	payload   map[string]interface{}
+	announce  *thermosAnnounce
+	logger    *ThermosLogger
+	lifecycle *ThermosLifecycle
+	overhead  *ExecutorOverhead
+}
+
+// Where Thermos writes the stdout and stderr of processes.
+type LogDestination string
+
+const (
+	LogToFile    LogDestination = "file"
+	LogToConsole LogDestination = "console"
+	LogToBoth    LogDestination = "both"
+	LogToNone    LogDestination = "none"
+)
+
+// Logging settings of the processes of a task. Logs written to the sandbox are rotated when
+// LogSizeMb is set, keeping at most MaxLogs old files, so they can't fill the agent's disk.
+type ThermosLogger struct {
+	Destination LogDestination
+	LogSizeMb   int64
+	MaxLogs     int
+}
+
+func (l *ThermosLogger) payload() map[string]interface{} {
+	logger := map[string]interface{}{"destination": l.Destination, "mode": "standard"}
+	if l.LogSizeMb > 0 {
+		logger["mode"] = "rotate"
+		logger["rotate"] = map[string]interface{}{
+			"log_size": l.LogSizeMb * 1024 * 1024,
+			"backups":  l.MaxLogs,
+		}
+	}
+	return logger
+}
+
+// Graceful shutdown of a task. When killed, e.g. during an update, Thermos first asks the
+// service to quit through GracefulShutdownEndpoint, then to abort through ShutdownEndpoint,
+// and finally kills its processes, waiting the given time between steps.
+type ThermosLifecycle struct {
+	// Named port, or port number, serving the shutdown endpoints.
+	Port string
+
+	// Default to /quitquitquit and /abortabortabort.
+	GracefulShutdownEndpoint string
+	ShutdownEndpoint         string
+
+	// Default to 5 seconds each.
+	GracefulShutdownWait time.Duration
+	ShutdownWait         time.Duration
+
+	// Time left to finalizing processes after the main processes ended, unchanged when zero.
+	FinalizationWait time.Duration
+}
+
+func (l *ThermosLifecycle) payload() map[string]interface{} {
+	http := map[string]interface{}{
+		"port":                        l.Port,
+		"graceful_shutdown_endpoint":  "/quitquitquit",
+		"shutdown_endpoint":           "/abortabortabort",
+		"graceful_shutdown_wait_secs": 5,
+		"shutdown_wait_secs":          5,
+	}
+	if l.GracefulShutdownEndpoint != "" {
+		http["graceful_shutdown_endpoint"] = l.GracefulShutdownEndpoint
+	}
+	if l.ShutdownEndpoint != "" {
+		http["shutdown_endpoint"] = l.ShutdownEndpoint
+	}
+	if l.GracefulShutdownWait > 0 {
+		http["graceful_shutdown_wait_secs"] = int(l.GracefulShutdownWait / time.Second)
+	}
+	if l.ShutdownWait > 0 {
+		http["shutdown_wait_secs"] = int(l.ShutdownWait / time.Second)
+	}
+	return map[string]interface{}{"http": http}
+}
+
+// Resources used by the executor itself out of those of the task, and the minimum left to the
+// task's processes below which ResourceWarnings reports a problem.
+type ExecutorOverhead struct {
+	CPU   float64
+	RamMb int64
+
+	MinUsableCPU   float64
+	MinUsableRamMb int64
+}
+
+// Resource left to the task's processes below the configured minimum.
+type ResourceWarning struct {
+	Resource string
+	Usable   float64
+	Minimum  float64
+}
+
+func (w ResourceWarning) String() string {
+	return fmt.Sprintf("Only %v %s usable after executor overhead, minimum is %v", w.Usable, w.Resource, w.Minimum)
+}
+
+type thermosAnnounce struct {
+	PrimaryPort string            `json:"primary_port"`
+	Portmap     map[string]string `json:"portmap,omitempty"`
+}
+
+// Create a builder from an existing Thermos payload.
+func NewThermosExecutor(payload string) (*ThermosExecutor, error) {
+	t := &ThermosExecutor{payload: make(map[string]interface{})}
+	if err := json.Unmarshal([]byte(payload), &t.payload); err != nil {
+		return nil, errors.Wrap(err, "Error parsing Thermos payload")
+	}
+	return t, nil
+}
+
+// Announce the task to a serverset with the given port as the service endpoint.
+func (t *ThermosExecutor) Announce(primaryPort string) *ThermosExecutor {
+	if t.announce == nil {
+		t.announce = &thermosAnnounce{Portmap: make(map[string]string)}
+	}
+	t.announce.PrimaryPort = primaryPort
+	return t
+}
+
+// Announce a named port under an alias, e.g. aurora -> http. The port may also be a fixed port
+// number. Enables announcing if it wasn't already, with the alias as the primary port.
+func (t *ThermosExecutor) PortMap(alias string, port string) *ThermosExecutor {
+	if t.announce == nil {
+		t.Announce(alias)
+	}
+	t.announce.Portmap[alias] = port
+	return t
+}
+
+// Use the given logging settings for every process of the task.
+func (t *ThermosExecutor) Logger(logger ThermosLogger) *ThermosExecutor {
+	t.logger = &logger
+	return t
+}
+
+// Shut the task's service down gracefully through HTTP endpoints before it is killed.
+func (t *ThermosExecutor) Lifecycle(lifecycle ThermosLifecycle) *ThermosExecutor {
+	t.lifecycle = &lifecycle
+	return t
+}
+
+// Account for the executor's own resource usage, see ResourceWarnings.
+func (t *ThermosExecutor) Overhead(overhead ExecutorOverhead) *ThermosExecutor {
+	t.overhead = &overhead
+	return t
+}
+
+// Resources of the job left to its processes after the executor overhead that fall below the
+// configured minimums, or are used up entirely. Tasks left with too little RAM are a common
+// source of OOM kills. Nothing is reported without an overhead.
+func (t *ThermosExecutor) ResourceWarnings(job *Job) []ResourceWarning {
+	if t.overhead == nil {
+		return nil
+	}
+
+	cpus, ramMb, _ := taskResources(job.jobConfig.TaskConfig)
+
+	var warnings []ResourceWarning
+	if usable := cpus - t.overhead.CPU; usable <= 0 || usable < t.overhead.MinUsableCPU {
+		warnings = append(warnings, ResourceWarning{Resource: "cpu", Usable: usable, Minimum: t.overhead.MinUsableCPU})
+	}
+	if usable := ramMb - t.overhead.RamMb; usable <= 0 || usable < t.overhead.MinUsableRamMb {
+		warnings = append(warnings, ResourceWarning{
+			Resource: "ram_mb",
+			Usable:   float64(usable),
+			Minimum:  float64(t.overhead.MinUsableRamMb)})
+	}
+	return warnings
+}
+
+// Check the payload against the job it is going to run in.
+func (t *ThermosExecutor) Validate(job *Job) error {
+	if t.lifecycle != nil {
+		if t.lifecycle.GracefulShutdownWait < 0 || t.lifecycle.ShutdownWait < 0 || t.lifecycle.FinalizationWait < 0 {
+			return errors.New("Shutdown and finalization waits can't be negative")
+		}
+
+		port := t.lifecycle.Port
+		if _, err := strconv.Atoi(port); err != nil && !job.namedPorts()[port] {
+			return errors.Errorf("Lifecycle port %q is not a named port of the job", port)
+		}
+	}
+
+	if t.logger != nil {
+		switch t.logger.Destination {
+		case LogToFile, LogToConsole, LogToBoth, LogToNone:
+		default:
+			return errors.Errorf("Unknown log destination %q", t.logger.Destination)
+		}
+
+		if t.logger.LogSizeMb < 0 || t.logger.MaxLogs < 0 {
+			return errors.New("Log size and max logs can't be negative")
+		}
+	}
+
+	if t.announce == nil {
+		return nil
+	}
+
+	ports := job.namedPorts()
+	for alias, port := range t.announce.Portmap {
+		if _, err := strconv.Atoi(port); err == nil {
+			continue
+		}
+		if !ports[port] {
+			return errors.Errorf("Port %q mapped to %q is not a named port of the job", port, alias)
+		}
+	}
+
+	primary := t.announce.PrimaryPort
+	if _, mapped := t.announce.Portmap[primary]; !mapped && !ports[primary] {
+		return errors.Errorf("Primary port %q is neither a named port of the job nor in the portmap", primary)
+	}
+
+	return nil
+}
+
+// Validate the payload and set it as the job's executor configuration.
+func (t *ThermosExecutor) Apply(job *Job) error {
+	if err := t.Validate(job); err != nil {
+		return err
+	}
+
+	if t.announce != nil {
+		t.payload["announce"] = t.announce
+	}
+
+	if t.logger != nil {
+		for _, process := range t.processes() {
+			process["logger"] = t.logger.payload()
+		}
+	}
+
+	if t.lifecycle != nil {
+		t.payload["lifecycle"] = t.lifecycle.payload()
+		if task, ok := t.payload["task"].(map[string]interface{}); ok && t.lifecycle.FinalizationWait > 0 {
+			task["finalization_wait"] = int(t.lifecycle.FinalizationWait / time.Second)
+		}
+	}
+
+	data, err := json.Marshal(t.payload)
+	if err != nil {
+		return errors.Wrap(err, "Error serializing Thermos payload")
+	}
+
+	job.ExecutorName(aurora.AURORA_EXECUTOR_NAME).ExecutorData(string(data))
+	return nil
+}
+
+// Processes of the task in the payload.
+func (t *ThermosExecutor) processes() []map[string]interface{} {
+	task, _ := t.payload["task"].(map[string]interface{})
+	list, _ := task["processes"].([]interface{})
+
+	var processes []map[string]interface{}
+	for _, p := range list {
+		if process, ok := p.(map[string]interface{}); ok {
+			processes = append(processes, process)
+		}
+	}
+	return processes
+}
+
+// Names of the ports requested by the job.
+func (a *Job) namedPorts() map[string]bool {
+	ports := make(map[string]bool)
+	for resource := range a.jobConfig.TaskConfig.Resources {
+		if resource.NamedPort != nil {
+			ports[*resource.NamedPort] = true
+		}
+	}
+	return ports
+}
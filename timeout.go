@@ -0,0 +1,88 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"net"
+	"time"
+)
+
+// Returned when a call to the scheduler timed out.
+type TimeoutError struct {
+	RPC string
+
+	// Timeout configured for HTTP requests, zero if unknown.
+	Timeout time.Duration
+	Elapsed time.Duration
+
+	// Whether the timeout hit while connecting, pointing at an unreachable or blackholed
+	// scheduler, rather than while waiting for a slow scheduler to answer.
+	Connecting bool
+
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	phase := "waiting for the scheduler to answer"
+	if e.Connecting {
+		phase = "connecting to the scheduler"
+	}
+	return fmt.Sprintf("%s timed out after %v (timeout %v) %s: %v",
+		e.RPC, e.Elapsed.Round(time.Millisecond), e.Timeout, phase, e.Err)
+}
+
+func (e *TimeoutError) Cause() error {
+	return e.Err
+}
+
+// Whether err was caused by a call timing out.
+func IsTimeout(err error) bool {
+	for _, e := range errorChain(err) {
+		if _, ok := e.(*TimeoutError); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Turn err into a *TimeoutError if it is a timeout, otherwise return it unchanged.
+func (r *RealisClient) timeoutError(name string, elapsed time.Duration, err error) error {
+	timedOut := false
+	connecting := false
+
+	for _, cause := range errorChain(err) {
+		switch e := cause.(type) {
+		case thrift.TTransportException:
+			timedOut = timedOut || e.TypeId() == thrift.TIMED_OUT
+		case *net.OpError:
+			connecting = connecting || e.Op == "dial"
+			timedOut = timedOut || e.Timeout()
+		case interface{ Timeout() bool }:
+			timedOut = timedOut || e.Timeout()
+		}
+	}
+
+	if !timedOut {
+		return err
+	}
+
+	timeoutErr := &TimeoutError{RPC: name, Elapsed: elapsed, Connecting: connecting, Err: err}
+	if r.config.httpClient != nil {
+		timeoutErr.Timeout = r.config.httpClient.Timeout
+	}
+	return timeoutErr
+}
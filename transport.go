@@ -0,0 +1,125 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"crypto/tls"
+	"github.com/pkg/errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Return the *http.Transport at the bottom of the configuration's HTTP stack, installing a
+// private copy of http.DefaultTransport if none was configured so it can be safely modified.
+func baseTransport(config *RealisConfig) (*http.Transport, error) {
+	if config.httpClient == nil {
+		return nil, errors.New("Configuration does not use an HTTP transport")
+	}
+
+	if config.httpClient.Transport == nil {
+		trans := http.DefaultTransport.(*http.Transport).Clone()
+		config.httpClient.Transport = trans
+		return trans, nil
+	}
+
+	roundTripper := config.httpClient.Transport
+	for {
+		switch trans := roundTripper.(type) {
+		case *http.Transport:
+			return trans, nil
+		case *loginTransport:
+			roundTripper = trans.next
+		case *bearerTransport:
+			roundTripper = trans.next
+		case *failoverTransport:
+			roundTripper = trans.next
+		case *gzipTransport:
+			roundTripper = trans.next
+		case *leaderTracker:
+			roundTripper = trans.next
+		default:
+			return nil, errors.Errorf("Unsupported HTTP transport %T", roundTripper)
+		}
+	}
+}
+
+// Authenticate with a client certificate for clusters that require mutual TLS at the proxy
+// layer. The files are checked on every handshake and reloaded when they are rotated.
+func WithClientCertificate(config *RealisConfig, certFile string, keyFile string) error {
+	reloader := &certReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := reloader.certificate(nil); err != nil {
+		return err
+	}
+
+	trans, err := baseTransport(config)
+	if err != nil {
+		return err
+	}
+
+	if trans.TLSClientConfig == nil {
+		trans.TLSClientConfig = &tls.Config{}
+	}
+	trans.TLSClientConfig.GetClientCertificate = reloader.certificate
+
+	return nil
+}
+
+type certReloader struct {
+	certFile string
+	keyFile  string
+	lock     sync.Mutex
+	cert     *tls.Certificate
+	modTime  time.Time
+}
+
+func (c *certReloader) certificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	modTime, err := latestModTime(c.certFile, c.keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error checking client certificate")
+	}
+
+	if c.cert != nil && !modTime.After(c.modTime) {
+		return c.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error loading client certificate")
+	}
+
+	c.cert = &cert
+	c.modTime = modTime
+	return c.cert, nil
+}
+
+func latestModTime(paths ...string) (time.Time, error) {
+	var latest time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
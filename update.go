@@ -0,0 +1,151 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"time"
+)
+
+// Wrapper around aurora.JobUpdateSummary exposing its fields with Go types.
+type UpdateSummary struct {
+	summary *aurora.JobUpdateSummary
+}
+
+func NewUpdateSummary(summary *aurora.JobUpdateSummary) *UpdateSummary {
+	return &UpdateSummary{summary: summary}
+}
+
+// Underlying thrift structure.
+func (u *UpdateSummary) JobUpdateSummary() *aurora.JobUpdateSummary {
+	return u.summary
+}
+
+func (u *UpdateSummary) Key() *aurora.JobUpdateKey {
+	return u.summary.Key
+}
+
+func (u *UpdateSummary) ID() string {
+	if u.summary.Key == nil {
+		return ""
+	}
+	return u.summary.Key.ID
+}
+
+func (u *UpdateSummary) JobKey() *aurora.JobKey {
+	if u.summary.Key == nil {
+		return nil
+	}
+	return u.summary.Key.Job
+}
+
+// User that started the update.
+func (u *UpdateSummary) User() string {
+	return u.summary.User
+}
+
+func (u *UpdateSummary) Status() aurora.JobUpdateStatus {
+	return u.state().Status
+}
+
+func (u *UpdateSummary) CreatedAt() time.Time {
+	return msToTime(u.state().CreatedTimestampMs)
+}
+
+func (u *UpdateSummary) LastModifiedAt() time.Time {
+	return msToTime(u.state().LastModifiedTimestampMs)
+}
+
+func (u *UpdateSummary) state() *aurora.JobUpdateState {
+	if u.summary.State == nil {
+		return &aurora.JobUpdateState{}
+	}
+	return u.summary.State
+}
+
+// Status change of an update.
+type UpdateEvent struct {
+	Time    time.Time
+	Status  aurora.JobUpdateStatus
+	User    string
+	Message string
+}
+
+// Action taken on a single instance during an update.
+type InstanceUpdateEvent struct {
+	Time       time.Time
+	InstanceID int32
+	Action     aurora.JobUpdateAction
+}
+
+// Wrapper around aurora.JobUpdateDetails exposing its fields with Go types.
+type UpdateDetails struct {
+	details *aurora.JobUpdateDetails
+}
+
+func NewUpdateDetails(details *aurora.JobUpdateDetails) *UpdateDetails {
+	return &UpdateDetails{details: details}
+}
+
+// Underlying thrift structure.
+func (d *UpdateDetails) JobUpdateDetails() *aurora.JobUpdateDetails {
+	return d.details
+}
+
+func (d *UpdateDetails) Summary() *UpdateSummary {
+	if d.details.Update == nil || d.details.Update.Summary == nil {
+		return NewUpdateSummary(&aurora.JobUpdateSummary{})
+	}
+	return NewUpdateSummary(d.details.Update.Summary)
+}
+
+// Status changes of the update, as returned by the scheduler.
+func (d *UpdateDetails) Events() []UpdateEvent {
+	events := make([]UpdateEvent, 0, len(d.details.UpdateEvents))
+	for _, event := range d.details.UpdateEvents {
+		updateEvent := UpdateEvent{Time: msToTime(event.TimestampMs), Status: event.Status}
+		if event.User != nil {
+			updateEvent.User = *event.User
+		}
+		if event.Message != nil {
+			updateEvent.Message = *event.Message
+		}
+		events = append(events, updateEvent)
+	}
+	return events
+}
+
+// Actions taken on instances during the update, as returned by the scheduler.
+func (d *UpdateDetails) InstanceEvents() []InstanceUpdateEvent {
+	events := make([]InstanceUpdateEvent, 0, len(d.details.InstanceEvents))
+	for _, event := range d.details.InstanceEvents {
+		events = append(events, InstanceUpdateEvent{
+			Time:       msToTime(event.TimestampMs),
+			InstanceID: event.InstanceId,
+			Action:     event.Action})
+	}
+	return events
+}
+
+// Time an instance last moved, or the creation time of the update if none did yet.
+func (d *UpdateDetails) LastProgressAt() time.Time {
+	latest := d.Summary().CreatedAt()
+	for _, event := range d.InstanceEvents() {
+		if event.Time.After(latest) {
+			latest = event.Time
+		}
+	}
+	return latest
+}
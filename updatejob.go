@@ -14,12 +14,32 @@
 
 package realis
 
-import "gen-go/apache/aurora"
+import (
+	"encoding/json"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Metadata key under which a job's preferred update settings are stored.
+const UpdateSettingsLabel = "gorealis.update_settings"
+
+// Layout of the update settings stored in job metadata.
+type jsonUpdateSettings struct {
+	BatchSize              int32  `json:"batch_size"`
+	WatchTimeMs            int32  `json:"watch_time_ms"`
+	WaitForBatchCompletion bool   `json:"wait_for_batch_completion"`
+	MaxPerInstanceFailures int32  `json:"max_per_instance_failures"`
+	MaxFailedInstances     int32  `json:"max_failed_instances"`
+	RollbackOnFailure      bool   `json:"rollback_on_failure"`
+	PulseIntervalMs        *int64 `json:"pulse_interval_ms,omitempty"`
+}
 
 // Structure to collect all information requrired to create job update
 type UpdateJob struct {
-	*Job // SetInstanceCount for job is hidden, access via full qualifier
-	req  *aurora.JobUpdateRequest
+	*Job           // SetInstanceCount for job is hidden, access via full qualifier
+	req            *aurora.JobUpdateRequest
+	overrideWindow bool
 }
 
 // Create a default UpdateJob object.
@@ -33,14 +53,81 @@ func NewUpdateJob(job *Job) *UpdateJob {
 	req.Settings.UpdateOnlyTheseInstances = make(map[*aurora.Range]bool)
 	req.Settings.UpdateGroupSize = 1
 	req.Settings.WaitForBatchCompletion = false
-	req.Settings.MinWaitInInstanceRunningMs = 45000 // Deprecated
+	req.Settings.MinWaitInInstanceRunningMs = durationToMs(45 * time.Second) // Deprecated
 	req.Settings.MaxPerInstanceFailures = 0
 	req.Settings.MaxFailedInstances = 0
 	req.Settings.RollbackOnFailure = true
 	req.Settings.WaitForBatchCompletion = false
 
 	//TODO(rdelvalle): Deep copy job struct to avoid unexpected behavior
-	return &UpdateJob{job, req}
+	return &UpdateJob{Job: job, req: req}
+}
+
+// Create an update for a job configuration, e.g. one returned by GetJobs, using the update
+// settings stored in its metadata by SaveSettings, if any, instead of the defaults.
+func UpdateJobFromConfig(jobConfig *aurora.JobConfiguration) (*UpdateJob, error) {
+	update := NewUpdateJob(NewJobFromConfig(jobConfig))
+	update.InstanceCount(jobConfig.InstanceCount)
+
+	for metadata := range jobConfig.TaskConfig.Metadata {
+		if metadata.Key != UpdateSettingsLabel {
+			continue
+		}
+
+		var stored jsonUpdateSettings
+		if err := json.Unmarshal([]byte(metadata.Value), &stored); err != nil {
+			return nil, errors.Wrap(err, "Error parsing update settings stored in job metadata")
+		}
+
+		settings := update.req.Settings
+		settings.UpdateGroupSize = stored.BatchSize
+		settings.MinWaitInInstanceRunningMs = stored.WatchTimeMs
+		settings.WaitForBatchCompletion = stored.WaitForBatchCompletion
+		settings.MaxPerInstanceFailures = stored.MaxPerInstanceFailures
+		settings.MaxFailedInstances = stored.MaxFailedInstances
+		settings.RollbackOnFailure = stored.RollbackOnFailure
+		if stored.PulseIntervalMs != nil {
+			pulse := int32(*stored.PulseIntervalMs)
+			settings.BlockIfNoPulsesAfterMs = &pulse
+		}
+	}
+
+	return update, nil
+}
+
+// Store the current update settings in the job's metadata, replacing any stored before, so
+// the job carries its own deploy policy for later updates built with UpdateJobFromConfig.
+// Instance restrictions such as those of the canary preset are not stored.
+func (u *UpdateJob) SaveSettings() *UpdateJob {
+	settings := u.req.Settings
+	stored := jsonUpdateSettings{
+		BatchSize:              settings.UpdateGroupSize,
+		WatchTimeMs:            settings.MinWaitInInstanceRunningMs,
+		WaitForBatchCompletion: settings.WaitForBatchCompletion,
+		MaxPerInstanceFailures: settings.MaxPerInstanceFailures,
+		MaxFailedInstances:     settings.MaxFailedInstances,
+		RollbackOnFailure:      settings.RollbackOnFailure}
+	if settings.BlockIfNoPulsesAfterMs != nil {
+		pulse := int64(*settings.BlockIfNoPulsesAfterMs)
+		stored.PulseIntervalMs = &pulse
+	}
+
+	// Marshaling a struct of plain fields can't fail.
+	data, _ := json.Marshal(stored)
+
+	metadata := u.jobConfig.TaskConfig.Metadata
+	for label := range metadata {
+		if label.Key == UpdateSettingsLabel {
+			delete(metadata, label)
+		}
+	}
+	u.AddLabel(UpdateSettingsLabel, string(data))
+	return u
+}
+
+// Update request sent to the scheduler.
+func (u *UpdateJob) Request() *aurora.JobUpdateRequest {
+	return u.req
 }
 
 // Set instance count the job will have after the update.
@@ -55,9 +142,17 @@ func (u *UpdateJob) BatchSize(size int32) *UpdateJob {
 	return u
 }
 
-// Minimum number of seconds a shard must remain in RUNNING state before considered a success.
-func (u *UpdateJob) WatchTime(milliseconds int32) *UpdateJob {
-	u.req.Settings.MaxPerInstanceFailures = milliseconds
+// Minimum amount of time a shard must remain in RUNNING state before considered a success.
+func (u *UpdateJob) WatchTime(watch time.Duration) *UpdateJob {
+	u.req.Settings.MinWaitInInstanceRunningMs = durationToMs(watch)
+	return u
+}
+
+// Block the update if no pulse is received through PulseJobUpdate within the interval. The
+// update resumes on the next pulse.
+func (u *UpdateJob) PulseInterval(interval time.Duration) *UpdateJob {
+	ms := durationToMs(interval)
+	u.req.Settings.BlockIfNoPulsesAfterMs = &ms
 	return u
 }
 
@@ -86,3 +181,60 @@ func (u *UpdateJob) RollbackOnFail(rollback bool) *UpdateJob {
 	u.req.Settings.RollbackOnFailure = rollback
 	return u
 }
+
+type UpdatePreset string
+
+const (
+	// Large batches that tolerate a few failures, for stateless services that can absorb churn.
+	FastUpdate UpdatePreset = "fast"
+
+	// One instance at a time with a long watch period and no tolerance for failures.
+	SafeUpdate UpdatePreset = "safe"
+
+	// Only updates instance 0 so the new configuration can be evaluated before a full roll out.
+	CanaryUpdate UpdatePreset = "canary"
+)
+
+// Apply a named set of update settings. Settings can still be tuned afterwards with the
+// individual setters. Unknown presets leave the settings untouched.
+func (u *UpdateJob) Preset(preset UpdatePreset) *UpdateJob {
+	settings := u.req.Settings
+
+	switch preset {
+	case FastUpdate:
+		settings.UpdateGroupSize = 10
+		settings.WaitForBatchCompletion = false
+		settings.MinWaitInInstanceRunningMs = durationToMs(15 * time.Second)
+		settings.MaxPerInstanceFailures = 1
+		settings.MaxFailedInstances = 2
+		settings.RollbackOnFailure = true
+	case SafeUpdate:
+		settings.UpdateGroupSize = 1
+		settings.WaitForBatchCompletion = true
+		settings.MinWaitInInstanceRunningMs = durationToMs(time.Minute)
+		settings.MaxPerInstanceFailures = 0
+		settings.MaxFailedInstances = 0
+		settings.RollbackOnFailure = true
+	case CanaryUpdate:
+		settings.UpdateGroupSize = 1
+		settings.WaitForBatchCompletion = true
+		settings.MinWaitInInstanceRunningMs = durationToMs(time.Minute)
+		settings.MaxPerInstanceFailures = 0
+		settings.MaxFailedInstances = 0
+		settings.RollbackOnFailure = true
+		settings.UpdateOnlyTheseInstances = map[*aurora.Range]bool{&aurora.Range{First: 0, Last: 0}: true}
+	}
+
+	return u
+}
+
+// Start the update even if it falls outside the deploy windows enforced by the client.
+func (u *UpdateJob) OverrideDeployWindow() *UpdateJob {
+	u.overrideWindow = true
+	return u
+}
+
+// Convert a duration to the milliseconds used by the thrift API.
+func durationToMs(d time.Duration) int32 {
+	return int32(d / time.Millisecond)
+}
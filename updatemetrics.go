@@ -0,0 +1,134 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"sort"
+	"time"
+)
+
+// Timing and failures of one batch of instances of an update.
+type BatchMetrics struct {
+	UpdateKey *aurora.JobUpdateKey
+
+	// Position of the batch in the update, starting at 0.
+	Batch     int
+	Instances []int32
+
+	// Instances of the batch whose last update attempt failed.
+	Failed int
+
+	Started  time.Time
+	Finished time.Time
+	Duration time.Duration
+}
+
+// Receives the metrics of every batch of the updates monitored by the client, e.g. to export
+// them to a metrics system and measure deploy velocity.
+type UpdateMetricsHook func(metrics BatchMetrics)
+
+// Report the metrics of each batch of the updates awaited with AwaitJobUpdate, including the
+// ones started by StartJobUpdates and StartJobUpdateAsync, or watched by a MonitorSet, as soon
+// as the batch finishes.
+// Instances are grouped into batches of the update's group size in the order the scheduler
+// started updating them. Should be set before the client is shared between goroutines.
+func (r *RealisClient) SetUpdateMetricsHook(hook UpdateMetricsHook) {
+	r.metricsHook = hook
+}
+
+// Per instance progress of an update, as told by its instance events.
+type instanceProgress struct {
+	id       int32
+	started  int64
+	finished int64
+	failed   bool
+}
+
+// Metrics of the batches of an update that finished, starting at batch first.
+func updateBatchMetrics(details *aurora.JobUpdateDetails, first int) []BatchMetrics {
+	progress := make(map[int32]*instanceProgress)
+	for _, event := range details.InstanceEvents {
+		p, ok := progress[event.InstanceId]
+		if !ok {
+			if event.Action != aurora.JobUpdateAction_INSTANCE_UPDATING {
+				continue
+			}
+			p = &instanceProgress{id: event.InstanceId, started: event.TimestampMs}
+			progress[event.InstanceId] = p
+		}
+
+		switch event.Action {
+		case aurora.JobUpdateAction_INSTANCE_UPDATING:
+			p.finished = 0
+		case aurora.JobUpdateAction_INSTANCE_UPDATED:
+			p.finished, p.failed = event.TimestampMs, false
+		case aurora.JobUpdateAction_INSTANCE_UPDATE_FAILED:
+			p.finished, p.failed = event.TimestampMs, true
+		}
+	}
+
+	instances := make([]*instanceProgress, 0, len(progress))
+	for _, p := range progress {
+		instances = append(instances, p)
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		if instances[i].started != instances[j].started {
+			return instances[i].started < instances[j].started
+		}
+		return instances[i].id < instances[j].id
+	})
+
+	size := 1
+	if settings := details.Update.Instructions.Settings; settings != nil && settings.UpdateGroupSize > 0 {
+		size = int(settings.UpdateGroupSize)
+	}
+
+	var metrics []BatchMetrics
+	for batch := first; batch*size < len(instances); batch++ {
+		end := (batch + 1) * size
+		if end > len(instances) {
+			end = len(instances)
+		}
+
+		m := BatchMetrics{UpdateKey: details.Update.Summary.Key, Batch: batch}
+		var started, finished int64
+		for _, p := range instances[batch*size : end] {
+			if p.finished == 0 {
+				return metrics
+			}
+			if started == 0 || p.started < started {
+				started = p.started
+			}
+			if p.finished > finished {
+				finished = p.finished
+			}
+			if p.failed {
+				m.Failed++
+			}
+			m.Instances = append(m.Instances, p.id)
+		}
+
+		// A partial last batch may still grow while the update is rolling forward.
+		if end-batch*size < size && !IsUpdateTerminal(details.Update.Summary.State.Status) {
+			return metrics
+		}
+
+		m.Started, m.Finished = msToTime(started), msToTime(finished)
+		m.Duration = m.Finished.Sub(m.Started)
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
@@ -0,0 +1,448 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default interval between queries to the scheduler when waiting on a job's state to change.
+const defaultPollInterval = 5 * time.Second
+
+// Block until no update is active for the job or the timeout expires. A zero timeout waits for
+// as long as it takes. Useful when several triggers may race to deploy the same job.
+func (r *RealisClient) AwaitUpdateSlot(key *aurora.JobKey, timeout time.Duration) error {
+	deadline := r.clock.Now().Add(timeout)
+	poll := r.newPoller()
+
+	for {
+		summaries, err := r.activeUpdateSummaries(key)
+		if err != nil {
+			return errors.Wrap(err, "Unable to determine if an update is active")
+		}
+
+		if len(summaries) == 0 {
+			return nil
+		}
+
+		wait := poll.next(len(summaries))
+		if timeout > 0 && r.clock.Now().Add(wait).After(deadline) {
+			return &ErrUpdateInProgress{
+				Key:     summaries[0].Key,
+				Message: "Timed out after " + timeout.String() + " waiting for the update to finish"}
+		}
+
+		r.clock.Sleep(wait)
+	}
+}
+
+// Block until the update reaches a terminal state or the timeout expires, returning the
+// last status observed. A zero timeout waits for as long as the update takes.
+func (r *RealisClient) AwaitJobUpdate(key *aurora.JobUpdateKey, timeout time.Duration) (aurora.JobUpdateStatus, error) {
+	if err := ValidateJobUpdateKey(key); err != nil {
+		return 0, err
+	}
+
+	deadline := r.clock.Now().Add(timeout)
+	poll := r.newPoller()
+	reported := 0
+
+	for {
+		var status aurora.JobUpdateStatus
+		if r.metricsHook == nil {
+			var err error
+			if status, err = r.jobUpdateStatus(key); err != nil {
+				return 0, err
+			}
+		} else {
+			details, err := r.JobUpdateDetails(key)
+			if err != nil {
+				return 0, err
+			}
+
+			status = details.Update.Summary.State.Status
+			for _, metrics := range updateBatchMetrics(details, reported) {
+				r.metricsHook(metrics)
+				reported++
+			}
+		}
+
+		if IsUpdateTerminal(status) {
+			return status, nil
+		}
+
+		wait := poll.next(status)
+		if timeout > 0 && r.clock.Now().Add(wait).After(deadline) {
+			return status, errors.Errorf("Timed out after %v waiting for update %s", timeout, key.ID)
+		}
+
+		r.clock.Sleep(wait)
+	}
+}
+
+// Block until the active tasks of a job went through no state transition for window, or the
+// timeout expires. Deploy pipelines use it before declaring a rollout healthy.
+func (r *RealisClient) AwaitStable(key *aurora.JobKey, window time.Duration, timeout time.Duration) error {
+	deadline := r.clock.Now().Add(timeout)
+	poll := r.newPoller()
+
+	var fingerprint string
+	var changedAt time.Time
+
+	for {
+		tasks, err := r.GetTasksWithoutConfigs(&aurora.TaskQuery{
+			Role:        key.Role,
+			Environment: key.Environment,
+			JobName:     key.Name,
+			Statuses:    aurora.ACTIVE_STATES})
+		if err != nil {
+			return errors.Wrap(err, "Unable to retrieve active tasks")
+		}
+
+		now := r.clock.Now()
+		if current := TaskFingerprint(tasks); changedAt.IsZero() || current != fingerprint {
+			// The first time around the scheduler's timestamps tell how long the job has
+			// been stable, later changes happened since the previous poll.
+			changedAt = now
+			if fingerprint == "" {
+				var lastMs int64
+				for _, task := range tasks {
+					if ms := lastEventMs(task); ms > lastMs {
+						lastMs = ms
+					}
+				}
+				if lastMs > 0 {
+					changedAt = msToTime(lastMs)
+				}
+			}
+			fingerprint = current
+		}
+
+		remaining := window - now.Sub(changedAt)
+		if remaining <= 0 {
+			return nil
+		}
+
+		wait := poll.next(fingerprint)
+		if wait > remaining {
+			wait = remaining
+		}
+		if now.Add(wait).After(deadline) {
+			return errors.Errorf("Timed out after %v waiting for job %s/%s/%s to be stable for %v",
+				timeout, key.Role, key.Environment, key.Name, window)
+		}
+
+		r.clock.Sleep(wait)
+	}
+}
+
+// Current status of an update.
+func (r *RealisClient) jobUpdateStatus(key *aurora.JobUpdateKey) (aurora.JobUpdateStatus, error) {
+	response, err := r.thriftCall("GetJobUpdateSummaries", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetJobUpdateSummaries(&aurora.JobUpdateQuery{Key: key})
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "Error querying Aurora Scheduler for update status")
+	}
+
+	if response.Result_ == nil || response.Result_.GetJobUpdateSummariesResult_ == nil ||
+		len(response.Result_.GetJobUpdateSummariesResult_.UpdateSummaries) == 0 {
+		return 0, errors.Errorf("Update %s not found", key.ID)
+	}
+
+	return response.Result_.GetJobUpdateSummariesResult_.UpdateSummaries[0].State.Status, nil
+}
+
+// Number of summaries fetched per call when paging through update history.
+const defaultUpdateHistoryPageSize = 100
+
+// Filters for paging through update history. Zero times leave the range open on that side.
+type UpdateHistoryQuery struct {
+	// Role, job, user and statuses to match. Offset and Limit are set while paging.
+	Query *aurora.JobUpdateQuery
+
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+
+	// Only updates that reached a terminal state within the range match when either is set.
+	CompletedAfter  time.Time
+	CompletedBefore time.Time
+
+	// Summaries fetched per call, defaults to 100.
+	PageSize int32
+}
+
+// Pages through the updates matching a history query, newest first.
+type UpdateHistory struct {
+	client *RealisClient
+	query  UpdateHistoryQuery
+	offset int32
+	done   bool
+}
+
+// Page through past updates, e.g. for deploy audit reports. Time filters are applied to each
+// page, so busy clusters are never loaded in a single call.
+func (r *RealisClient) UpdateHistory(query UpdateHistoryQuery) *UpdateHistory {
+	if query.Query == nil {
+		query.Query = &aurora.JobUpdateQuery{}
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = defaultUpdateHistoryPageSize
+	}
+	return &UpdateHistory{client: r, query: query}
+}
+
+// Whether every page was fetched.
+func (h *UpdateHistory) Done() bool {
+	return h.done
+}
+
+// Fetch the next page, keeping only the updates within the time ranges. A page may be empty
+// even though more follow, check Done.
+func (h *UpdateHistory) Next() ([]*aurora.JobUpdateSummary, error) {
+	if h.done {
+		return nil, nil
+	}
+
+	query := *h.query.Query
+	query.Offset = h.offset
+	query.Limit = h.query.PageSize
+
+	response, err := h.client.thriftCall("GetJobUpdateSummaries", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetJobUpdateSummaries(&query)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for update history")
+	}
+
+	var summaries []*aurora.JobUpdateSummary
+	if response.Result_ != nil && response.Result_.GetJobUpdateSummariesResult_ != nil {
+		summaries = response.Result_.GetJobUpdateSummariesResult_.UpdateSummaries
+	}
+
+	h.offset += int32(len(summaries))
+	if int32(len(summaries)) < h.query.PageSize {
+		h.done = true
+	}
+
+	var matching []*aurora.JobUpdateSummary
+	for _, summary := range summaries {
+		if summary.State == nil {
+			continue
+		}
+
+		// Updates come newest first, nothing older can match.
+		created := msToTime(summary.State.CreatedTimestampMs)
+		if !h.query.CreatedAfter.IsZero() && created.Before(h.query.CreatedAfter) {
+			h.done = true
+			break
+		}
+
+		if h.query.matches(summary.State) {
+			matching = append(matching, summary)
+		}
+	}
+
+	return matching, nil
+}
+
+// Fetch all remaining pages.
+func (h *UpdateHistory) All() ([]*aurora.JobUpdateSummary, error) {
+	var all []*aurora.JobUpdateSummary
+	for !h.done {
+		page, err := h.Next()
+		if err != nil {
+			return all, err
+		}
+		all = append(all, page...)
+	}
+	return all, nil
+}
+
+func (q UpdateHistoryQuery) matches(state *aurora.JobUpdateState) bool {
+	created := msToTime(state.CreatedTimestampMs)
+	if !q.CreatedBefore.IsZero() && !created.Before(q.CreatedBefore) {
+		return false
+	}
+
+	if q.CompletedAfter.IsZero() && q.CompletedBefore.IsZero() {
+		return true
+	}
+
+	if !IsUpdateTerminal(state.Status) {
+		return false
+	}
+
+	completed := msToTime(state.LastModifiedTimestampMs)
+	return (q.CompletedAfter.IsZero() || !completed.Before(q.CompletedAfter)) &&
+		(q.CompletedBefore.IsZero() || completed.Before(q.CompletedBefore))
+}
+
+// Options shared by all updates started through StartJobUpdates.
+type BatchUpdateSettings struct {
+	// Replaces the settings of every update when set.
+	Settings *aurora.JobUpdateSettings
+
+	Message string
+
+	// Maximum number of updates in flight at any given moment, unlimited when zero.
+	MaxConcurrent int
+
+	// How long to wait for each individual update to reach a terminal state, no limit when zero.
+	Timeout time.Duration
+}
+
+// Outcome of a single update started as part of a batch.
+type UpdateResult struct {
+	JobKey    *aurora.JobKey
+	UpdateKey *aurora.JobUpdateKey
+	Status    aurora.JobUpdateStatus
+	Err       error
+}
+
+// Aggregated outcome of a batch of updates. Results are in the same order as the updates.
+type BatchUpdateReport struct {
+	Results   []UpdateResult
+	Succeeded int
+	Failed    int
+}
+
+// Start updates for many jobs with shared settings and wait for all of them to finish. At most
+// settings.MaxConcurrent updates are started and tracked at the same time.
+func (r *RealisClient) StartJobUpdates(updates []*UpdateJob, settings BatchUpdateSettings) *BatchUpdateReport {
+	limit := settings.MaxConcurrent
+	if limit <= 0 {
+		limit = len(updates)
+	}
+
+	results := make([]UpdateResult, len(updates))
+	slots := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i, update := range updates {
+		if settings.Settings != nil {
+			update.req.Settings = copyUpdateSettings(settings.Settings)
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, update *UpdateJob) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = r.runJobUpdate(update, settings.Message, settings.Timeout)
+		}(i, update)
+	}
+	wg.Wait()
+
+	report := &BatchUpdateReport{Results: results}
+	for _, result := range results {
+		if result.Err == nil && result.Status == aurora.JobUpdateStatus_ROLLED_FORWARD {
+			report.Succeeded++
+		} else {
+			report.Failed++
+		}
+	}
+
+	return report
+}
+
+// Each update gets its own settings so changes made through one UpdateJob don't leak into the
+// others or back into the batch settings.
+func copyUpdateSettings(settings *aurora.JobUpdateSettings) *aurora.JobUpdateSettings {
+	settingsCopy := *settings
+
+	settingsCopy.UpdateOnlyTheseInstances = make(map[*aurora.Range]bool, len(settings.UpdateOnlyTheseInstances))
+	for instances, ok := range settings.UpdateOnlyTheseInstances {
+		settingsCopy.UpdateOnlyTheseInstances[&aurora.Range{First: instances.First, Last: instances.Last}] = ok
+	}
+
+	if settings.BlockIfNoPulsesAfterMs != nil {
+		ms := *settings.BlockIfNoPulsesAfterMs
+		settingsCopy.BlockIfNoPulsesAfterMs = &ms
+	}
+
+	return &settingsCopy
+}
+
+func (r *RealisClient) runJobUpdate(update *UpdateJob, message string, timeout time.Duration) UpdateResult {
+	result := UpdateResult{JobKey: update.JobKey()}
+
+	response, err := r.StartJobUpdate(update, message)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if response.ResponseCode != aurora.ResponseCode_OK || response.Result_ == nil ||
+		response.Result_.StartJobUpdateResult_ == nil {
+		result.Err = errors.Errorf("Update rejected by Aurora Scheduler: %s", responseMessage(response))
+		return result
+	}
+
+	result.UpdateKey = response.Result_.StartJobUpdateResult_.Key
+	result.Status, result.Err = r.AwaitJobUpdate(result.UpdateKey, timeout)
+	return result
+}
+
+// Abort every active update under a role. Meant as a break-glass stop during incidents, so
+// all updates are attempted even if some aborts fail. Returns the keys of the updates aborted.
+func (r *RealisClient) AbortAllUpdates(role string, message string) ([]*aurora.JobUpdateKey, error) {
+	response, err := r.thriftCall("GetJobUpdateSummaries", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetJobUpdateSummaries(&aurora.JobUpdateQuery{
+			Role:           role,
+			UpdateStatuses: aurora.ACTIVE_JOB_UPDATE_STATES})
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for active updates")
+	}
+
+	if response.Result_ == nil || response.Result_.GetJobUpdateSummariesResult_ == nil {
+		return nil, nil
+	}
+
+	var aborted []*aurora.JobUpdateKey
+	var failed []string
+	for _, summary := range response.Result_.GetJobUpdateSummariesResult_.UpdateSummaries {
+		key := summary.Key
+		op := &Operation{Name: "AbortJobUpdate", JobKey: key.Job}
+		if err := r.authorize(op); err != nil {
+			failed = append(failed, key.ID+": "+err.Error())
+			continue
+		}
+
+		resp, err := r.thriftCallAs(op, "AbortJobUpdate", func(conn *connection) (*aurora.Response, error) {
+			return conn.client.AbortJobUpdate(key, message)
+		})
+		if err == nil && resp.ResponseCode != aurora.ResponseCode_OK {
+			err = errors.New(responseMessage(resp))
+		}
+
+		if err != nil {
+			failed = append(failed, key.ID+": "+err.Error())
+			continue
+		}
+		aborted = append(aborted, key)
+	}
+
+	if len(failed) > 0 {
+		return aborted, errors.Errorf("Unable to abort %d update(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	return aborted, nil
+}
@@ -0,0 +1,79 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"github.com/pkg/errors"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Default path at which the scheduler serves its thrift API.
+const defaultAPIPath = "/api"
+
+// Turn a scheduler address given as host, host:port or a full URL, with or without scheme
+// and API path, into the base URL of the scheduler, e.g. http://host:8081. IPv6 literals
+// are accepted bare when no port is given, otherwise in brackets as in [::1]:8081.
+func normalizeURL(addr string, apiPath string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return "", errors.New("Scheduler URL is empty")
+	}
+
+	if !strings.Contains(addr, "://") {
+		// Bare IPv6 literals must be bracketed to be told apart from a port.
+		if ip := net.ParseIP(addr); ip != nil && strings.Contains(addr, ":") {
+			addr = "[" + addr + "]"
+		}
+		addr = "http://" + addr
+	}
+
+	parsed, err := url.Parse(addr)
+	if err != nil {
+		return "", errors.Wrapf(err, "Malformed scheduler URL %q", addr)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", errors.Errorf("Unsupported scheme %q in scheduler URL %q", parsed.Scheme, addr)
+	}
+
+	if parsed.Hostname() == "" {
+		return "", errors.Errorf("Missing host in scheduler URL %q", addr)
+	}
+
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", errors.Errorf("Scheduler URL %q must not have a query or fragment", addr)
+	}
+
+	parsed.Path = strings.TrimSuffix(strings.TrimRight(parsed.Path, "/"), apiPath)
+	parsed.RawPath = ""
+
+	return parsed.String(), nil
+}
+
+// Make sure an API path starts with a single slash and has no trailing one.
+func normalizeAPIPath(apiPath string) (string, error) {
+	apiPath = "/" + strings.Trim(strings.TrimSpace(apiPath), "/")
+	if apiPath == "/" {
+		return "", errors.New("API path is empty")
+	}
+
+	if strings.ContainsAny(apiPath, "?#") {
+		return "", errors.Errorf("API path %q must not have a query or fragment", apiPath)
+	}
+
+	return apiPath, nil
+}
@@ -0,0 +1,121 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"time"
+)
+
+// Returned when a mutation the scheduler accepted did not take effect within the verification
+// timeout, see SetVerifyMutations.
+type MutationNotVerifiedError struct {
+	Operation string
+	JobKey    *aurora.JobKey
+	Reason    string
+	Err       error
+}
+
+func (e *MutationNotVerifiedError) Error() string {
+	msg := fmt.Sprintf("%s of job %s/%s/%s not verified: %s",
+		e.Operation, e.JobKey.Role, e.JobKey.Environment, e.JobKey.Name, e.Reason)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *MutationNotVerifiedError) Cause() error {
+	return e.Err
+}
+
+// Whether err, or one it wraps, is a MutationNotVerifiedError.
+func IsMutationNotVerified(err error) bool {
+	for _, e := range errorChain(err) {
+		if _, ok := e.(*MutationNotVerifiedError); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Read back the effect of mutations the scheduler accepted, waiting up to timeout for it:
+// CreateJob waits for the job to be listed by GetJobs and KillJob for the killed instances to
+// leave the active states. Mutations failing verification return a MutationNotVerifiedError
+// along with the scheduler's response. A zero timeout, the default, disables verification.
+func (r *RealisClient) SetVerifyMutations(timeout time.Duration) {
+	r.settingsLock.Lock()
+	defer r.settingsLock.Unlock()
+	r.verifyTimeout = timeout
+}
+
+func (r *RealisClient) verifyMutationsTimeout() time.Duration {
+	r.settingsLock.RLock()
+	defer r.settingsLock.RUnlock()
+	return r.verifyTimeout
+}
+
+func (r *RealisClient) verifyCreate(key *aurora.JobKey) error {
+	return r.verify("CreateJob", key, "job not listed by the scheduler", func() (bool, error) {
+		configs, err := r.GetJobs(key.Role)
+		if err != nil {
+			return false, err
+		}
+		for _, config := range configs {
+			if *config.Key == *key {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+func (r *RealisClient) verifyKill(key *aurora.JobKey, instanceIds map[int32]bool) error {
+	return r.verify("KillJob", key, "instances still active", func() (bool, error) {
+		tasks, err := r.GetTasksWithoutConfigs(&aurora.TaskQuery{
+			Role:        key.Role,
+			Environment: key.Environment,
+			JobName:     key.Name,
+			InstanceIds: instanceIds,
+			Statuses:    aurora.ACTIVE_STATES})
+		if err != nil {
+			return false, err
+		}
+		return len(tasks) == 0, nil
+	})
+}
+
+// Poll check until it reports the mutation took effect or the verification timeout passes.
+func (r *RealisClient) verify(operation string, key *aurora.JobKey, reason string, check func() (bool, error)) error {
+	deadline := r.clock.Now().Add(r.verifyMutationsTimeout())
+	poll := r.newPoller()
+
+	for {
+		done, err := check()
+		if err != nil {
+			return &MutationNotVerifiedError{Operation: operation, JobKey: key, Reason: "unable to query the scheduler", Err: err}
+		}
+		if done {
+			return nil
+		}
+
+		wait := poll.next(done)
+		if r.clock.Now().Add(wait).After(deadline) {
+			return &MutationNotVerifiedError{Operation: operation, JobKey: key, Reason: reason}
+		}
+		r.clock.Sleep(wait)
+	}
+}
@@ -0,0 +1,164 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+type WatchdogAction int
+
+const (
+	// Only publish a StuckUpdateEvent.
+	WatchdogNotify WatchdogAction = iota
+	WatchdogPause
+	WatchdogAbort
+)
+
+// Settings of an update watchdog.
+type WatchdogConfig struct {
+	// Role whose updates are watched, every role when empty.
+	Role string
+
+	// How long an update may go without any instance making progress.
+	MaxStall time.Duration
+
+	// What to do with stalled updates besides publishing an event.
+	Action WatchdogAction
+
+	// How often updates are checked, every 5 seconds when zero.
+	Interval time.Duration
+
+	// Message recorded with the pause or abort.
+	Message string
+}
+
+// Watches active updates and acts on those that stopped making progress, preventing
+// forgotten half-finished deploys. Every stalled update results in a single StuckUpdateEvent
+// on the client's event channel until it makes progress again. Paused updates are left alone.
+type UpdateWatchdog struct {
+	client *RealisClient
+	config WatchdogConfig
+
+	// Last progress of the stalled updates already acted on, by update id.
+	lock    sync.Mutex
+	handled map[string]time.Time
+}
+
+func NewUpdateWatchdog(client *RealisClient, config WatchdogConfig) *UpdateWatchdog {
+	if config.Interval <= 0 {
+		config.Interval = defaultPollInterval
+	}
+	return &UpdateWatchdog{client: client, config: config, handled: make(map[string]time.Time)}
+}
+
+// Check updates every interval until stop is closed.
+func (w *UpdateWatchdog) Run(stop <-chan struct{}) {
+	for {
+		if _, err := w.Check(); err != nil {
+			w.client.emit(Event{Type: WatchdogErrorEvent, Message: err.Error(), Payload: err})
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-w.client.clock.After(w.config.Interval):
+		}
+	}
+}
+
+// Check all active updates once, acting on the stalled ones not acted on yet. Returns the
+// updates acted on.
+func (w *UpdateWatchdog) Check() ([]*aurora.JobUpdateKey, error) {
+	response, err := w.client.thriftCall("GetJobUpdateSummaries", func(conn *connection) (*aurora.Response, error) {
+		return conn.client.GetJobUpdateSummaries(&aurora.JobUpdateQuery{
+			Role:           w.config.Role,
+			UpdateStatuses: aurora.ACTIVE_JOB_UPDATE_STATES})
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying Aurora Scheduler for active updates")
+	}
+
+	if response.Result_ == nil || response.Result_.GetJobUpdateSummariesResult_ == nil {
+		return nil, nil
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	active := make(map[string]bool)
+	var stalled []*aurora.JobUpdateKey
+	for _, summary := range response.Result_.GetJobUpdateSummariesResult_.UpdateSummaries {
+		active[summary.Key.ID] = true
+		if IsUpdatePaused(summary.State.Status) {
+			continue
+		}
+
+		details, err := w.client.JobUpdateDetails(summary.Key)
+		if err != nil {
+			return stalled, err
+		}
+
+		lastProgress := NewUpdateDetails(details).LastProgressAt()
+		stall := w.client.clock.Now().Sub(lastProgress)
+		if stall < w.config.MaxStall {
+			continue
+		}
+
+		if handled, ok := w.handled[summary.Key.ID]; ok && handled.Equal(lastProgress) {
+			continue
+		}
+
+		stalled = append(stalled, summary.Key)
+		w.client.emit(Event{
+			Type:    StuckUpdateEvent,
+			JobKey:  summary.Key.Job,
+			Message: fmt.Sprintf("Update %s made no progress for %v", summary.Key.ID, stall.Round(time.Second)),
+			Payload: summary.Key})
+
+		if err := w.act(summary); err != nil {
+			return stalled, err
+		}
+		w.handled[summary.Key.ID] = lastProgress
+	}
+
+	for id := range w.handled {
+		if !active[id] {
+			delete(w.handled, id)
+		}
+	}
+
+	return stalled, nil
+}
+
+func (w *UpdateWatchdog) act(summary *aurora.JobUpdateSummary) error {
+	var err error
+
+	switch w.config.Action {
+	case WatchdogPause:
+		if IsUpdatePaused(summary.State.Status) {
+			return nil
+		}
+		_, err = w.client.PauseJobUpdate(summary.Key, w.config.Message)
+	case WatchdogAbort:
+		_, err = w.client.AbortJobUpdate(summary.Key.Job, summary.Key.ID, w.config.Message)
+	}
+
+	return err
+}
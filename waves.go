@@ -0,0 +1,121 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+// Pacing of a restart in waves.
+type RestartWaves struct {
+	// Share of the job's active instances, in percent, restarted at the same time. Waves
+	// restart at least one instance.
+	MaxUnavailablePercent float64
+
+	// Pause after the instances of a wave are running again, before the next wave starts.
+	WaveDelay time.Duration
+
+	// Time allowed for the whole restart, no limit when zero.
+	Timeout time.Duration
+}
+
+// Restart the active instances of a job in waves instead of all at once like RestartJob. Each
+// wave must be running again before the delay and the next wave start.
+func (r *RealisClient) RestartJobInWaves(key *aurora.JobKey, waves RestartWaves) error {
+	if waves.MaxUnavailablePercent <= 0 || waves.MaxUnavailablePercent > 100 {
+		return errors.Errorf("Max unavailable percentage must be in (0, 100], got %v", waves.MaxUnavailablePercent)
+	}
+
+	op := &Operation{Name: "RestartJob", JobKey: key}
+	if err := r.authorize(op); err != nil {
+		return err
+	}
+
+	// Waves are cut from the instances read from the leader at the start.
+	fence := r.Fence()
+	var deadline time.Time
+	if waves.Timeout > 0 {
+		deadline = r.clock.Now().Add(waves.Timeout)
+	}
+
+	instanceIds, err := r.getActiveInstanceIds(key)
+	if err != nil {
+		return errors.Wrap(err, "Could not retrieve relevant task instance IDs.")
+	}
+	if len(instanceIds) == 0 {
+		return errors.New("No tasks in the Active state.")
+	}
+
+	before, err := runningTaskIds(r, key)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int32, 0, len(instanceIds))
+	for id := range instanceIds {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	size := int(float64(len(ids)) * waves.MaxUnavailablePercent / 100)
+	if size < 1 {
+		size = 1
+	}
+
+	for start := 0; start < len(ids); start += size {
+		end := start + size
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		wave := make(map[int32]bool)
+		for _, id := range ids[start:end] {
+			wave[id] = true
+		}
+
+		if err := fence.Check(); err != nil {
+			return err
+		}
+
+		response, err := r.thriftCallAs(op, "RestartShards", func(conn *connection) (*aurora.Response, error) {
+			return conn.client.RestartShards(key, wave)
+		})
+		if err != nil {
+			return errors.Wrap(err, "Error sending Restart command to Aurora Scheduler.")
+		}
+		if isUpdateInProgress(response) {
+			return r.updateInProgressError(key, response)
+		}
+		if response.ResponseCode != aurora.ResponseCode_OK {
+			return errors.Errorf("Restart rejected by Aurora Scheduler: %s", responseMessage(response))
+		}
+
+		if err := r.awaitRestarted(key, wave, before, deadline); err != nil {
+			return errors.Wrapf(err, "Wave of instances %v did not come back", ids[start:end])
+		}
+
+		if end < len(ids) && waves.WaveDelay > 0 {
+			if !deadline.IsZero() && r.clock.Now().Add(waves.WaveDelay).After(deadline) {
+				return errors.Errorf("Timed out after %v with %d of %d instances restarted", waves.Timeout, end, len(ids))
+			}
+			r.clock.Sleep(waves.WaveDelay)
+		}
+	}
+
+	return nil
+}
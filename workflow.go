@@ -0,0 +1,242 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"fmt"
+	"gen-go/apache/aurora"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// What a workflow does once a step has failed all its attempts.
+type FailurePolicy int
+
+const (
+	// Start no new steps, let running ones finish.
+	FailFast FailurePolicy = iota
+
+	// Keep running every step that doesn't depend on a failed one.
+	ContinueOnFailure
+)
+
+// Ad-hoc task run as part of a workflow.
+type WorkflowStep struct {
+	// Unique within the workflow, used as job name.
+	Name        string
+	Role        string
+	Environment string
+	Command     string
+	Resources   AdhocResources
+
+	// Steps that must succeed before this one starts.
+	DependsOn []string
+
+	// Additional attempts after a failure. Retries run as <name>-retry<n>.
+	Retries int
+	Timeout time.Duration
+}
+
+// Set of steps forming a directed acyclic graph through their dependencies.
+type Workflow struct {
+	Steps  []WorkflowStep
+	Policy FailurePolicy
+
+	// Steps running at the same time, unlimited when 0.
+	MaxParallel int
+}
+
+// Outcome of a workflow step.
+type StepResult struct {
+	Name     string
+	Attempts int
+
+	// Final state of the last attempt.
+	Task *Task
+	Err  error
+
+	// Set when the step never ran because a dependency failed or the workflow stopped.
+	Skipped bool
+}
+
+func (s *StepResult) Succeeded() bool {
+	return !s.Skipped && s.Err == nil
+}
+
+// Run the steps of a workflow as ad-hoc tasks, each starting once its dependencies succeeded.
+// Returns the result of every step and an error if the workflow is invalid or any step did
+// not succeed.
+func (r *RealisClient) RunWorkflow(workflow Workflow) (map[string]*StepResult, error) {
+	return runWorkflow(workflow, r.runStep)
+}
+
+// Schedule the steps of a workflow, running each through run.
+func runWorkflow(workflow Workflow, run func(step WorkflowStep) *StepResult) (map[string]*StepResult, error) {
+	steps, err := validateWorkflow(workflow)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*StepResult)
+	done := make(chan *StepResult)
+	running := 0
+	failed := false
+
+	// Every step may be in results while some of them are still running.
+	for len(results) < len(steps) || running > 0 {
+		// Skipping a step may block or skip steps listed before it, so steps are passed over
+		// until none changes state.
+		for changed := true; changed; {
+			changed = false
+			for _, step := range workflow.Steps {
+				if _, seen := results[step.Name]; seen {
+					continue
+				}
+				if workflow.MaxParallel > 0 && running >= workflow.MaxParallel {
+					break
+				}
+
+				ready, blocked := dependencyState(step, results)
+				if blocked || (failed && workflow.Policy == FailFast) {
+					results[step.Name] = &StepResult{Name: step.Name, Skipped: true}
+					changed = true
+					continue
+				}
+				if !ready {
+					continue
+				}
+
+				// Placeholder marks the step as started until its result comes back.
+				results[step.Name] = nil
+				running++
+				changed = true
+				go func(step WorkflowStep) {
+					done <- run(step)
+				}(step)
+			}
+		}
+
+		if running == 0 {
+			break
+		}
+
+		result := <-done
+		running--
+		results[result.Name] = result
+		if !result.Succeeded() {
+			failed = true
+		}
+	}
+
+	for _, result := range results {
+		if result == nil || !result.Succeeded() {
+			return results, errors.New("Workflow did not complete successfully")
+		}
+	}
+	return results, nil
+}
+
+// Whether all dependencies of a step succeeded, or any of them won't.
+func dependencyState(step WorkflowStep, results map[string]*StepResult) (ready bool, blocked bool) {
+	ready = true
+	for _, dep := range step.DependsOn {
+		result, seen := results[dep]
+		switch {
+		case !seen || result == nil:
+			ready = false
+		case !result.Succeeded():
+			return false, true
+		}
+	}
+	return ready, false
+}
+
+func (r *RealisClient) runStep(step WorkflowStep) *StepResult {
+	result := &StepResult{Name: step.Name}
+
+	for attempt := 0; attempt <= step.Retries; attempt++ {
+		name := step.Name
+		if attempt > 0 {
+			name = fmt.Sprintf("%s-retry%d", step.Name, attempt)
+		}
+
+		result.Attempts++
+		task, err := r.RunAdhocTask(step.Role, step.Environment, name, step.Command, step.Resources, step.Timeout)
+		result.Task = task
+		result.Err = err
+
+		if err == nil && task.Status() != aurora.ScheduleStatus_FINISHED {
+			result.Err = errors.Errorf("Step %s ended as %v", name, task.Status())
+		}
+		if result.Err == nil {
+			break
+		}
+	}
+
+	return result
+}
+
+// Check that step names are unique, dependencies exist and there are no cycles.
+func validateWorkflow(workflow Workflow) (map[string]WorkflowStep, error) {
+	steps := make(map[string]WorkflowStep)
+	for _, step := range workflow.Steps {
+		if _, dup := steps[step.Name]; dup {
+			return nil, errors.Errorf("Duplicate workflow step %s", step.Name)
+		}
+		steps[step.Name] = step
+	}
+
+	for _, step := range workflow.Steps {
+		for _, dep := range step.DependsOn {
+			if _, ok := steps[dep]; !ok {
+				return nil, errors.Errorf("Step %s depends on unknown step %s", step.Name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return errors.Errorf("Workflow has a dependency cycle through step %s", name)
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		for _, dep := range steps[name].DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+
+	for _, step := range workflow.Steps {
+		if err := visit(step.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	return steps, nil
+}
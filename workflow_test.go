@@ -0,0 +1,173 @@
+/**
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package realis
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunWorkflow(t *testing.T) {
+	step := func(name string, deps ...string) WorkflowStep {
+		return WorkflowStep{Name: name, DependsOn: deps}
+	}
+
+	tests := []struct {
+		name     string
+		workflow Workflow
+		failing  map[string]bool
+		ran      []string
+		outcomes map[string]string
+	}{
+		{
+			name:     "chain",
+			workflow: Workflow{Steps: []WorkflowStep{step("c", "b"), step("b", "a"), step("a")}},
+			ran:      []string{"a", "b", "c"},
+			outcomes: map[string]string{"a": "ok", "b": "ok", "c": "ok"},
+		},
+		{
+			name:     "fail fast",
+			workflow: Workflow{Steps: []WorkflowStep{step("a"), step("b", "a"), step("c")}, MaxParallel: 1},
+			failing:  map[string]bool{"a": true},
+			ran:      []string{"a"},
+			outcomes: map[string]string{"a": "failed", "b": "skipped", "c": "skipped"},
+		},
+		{
+			name: "continue on failure",
+			workflow: Workflow{
+				Steps:       []WorkflowStep{step("a"), step("b", "a"), step("c")},
+				Policy:      ContinueOnFailure,
+				MaxParallel: 1},
+			failing:  map[string]bool{"a": true},
+			ran:      []string{"a", "c"},
+			outcomes: map[string]string{"a": "failed", "b": "skipped", "c": "ok"},
+		},
+		{
+			name: "skips dependents listed first",
+			workflow: Workflow{
+				Steps:  []WorkflowStep{step("d", "c"), step("c", "b"), step("b", "a"), step("a")},
+				Policy: ContinueOnFailure},
+			failing:  map[string]bool{"a": true},
+			ran:      []string{"a"},
+			outcomes: map[string]string{"a": "failed", "b": "skipped", "c": "skipped", "d": "skipped"},
+		},
+		{
+			name:     "diamond",
+			workflow: Workflow{Steps: []WorkflowStep{step("a"), step("b", "a"), step("c", "a"), step("d", "b", "c")}, MaxParallel: 1},
+			ran:      []string{"a", "b", "c", "d"},
+			outcomes: map[string]string{"a": "ok", "b": "ok", "c": "ok", "d": "ok"},
+		},
+	}
+
+	for _, test := range tests {
+		var lock sync.Mutex
+		var ran []string
+		results, err := runWorkflow(test.workflow, func(step WorkflowStep) *StepResult {
+			lock.Lock()
+			ran = append(ran, step.Name)
+			lock.Unlock()
+
+			result := &StepResult{Name: step.Name, Attempts: 1}
+			if test.failing[step.Name] {
+				result.Err = errors.New("exit code 1")
+			}
+			return result
+		})
+
+		if !reflect.DeepEqual(ran, test.ran) {
+			t.Errorf("%s: ran %v, want %v", test.name, ran, test.ran)
+		}
+
+		outcomes := make(map[string]string)
+		for name, result := range results {
+			switch {
+			case result == nil:
+				outcomes[name] = "running"
+			case result.Skipped:
+				outcomes[name] = "skipped"
+			case result.Err != nil:
+				outcomes[name] = "failed"
+			default:
+				outcomes[name] = "ok"
+			}
+		}
+		if !reflect.DeepEqual(outcomes, test.outcomes) {
+			t.Errorf("%s: outcomes %v, want %v", test.name, outcomes, test.outcomes)
+		}
+
+		if succeeded := len(test.failing) == 0; (err == nil) != succeeded {
+			t.Errorf("%s: err = %v, want success %v", test.name, err, succeeded)
+		}
+	}
+}
+
+func TestRunWorkflowMaxParallel(t *testing.T) {
+	var steps []WorkflowStep
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		steps = append(steps, WorkflowStep{Name: name})
+	}
+
+	var lock sync.Mutex
+	running, peak := 0, 0
+	_, err := runWorkflow(Workflow{Steps: steps, MaxParallel: 2}, func(step WorkflowStep) *StepResult {
+		lock.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		lock.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return &StepResult{Name: step.Name}
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak > 2 {
+		t.Errorf("%d steps ran at once, want at most 2", peak)
+	}
+}
+
+func TestValidateWorkflow(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []WorkflowStep
+		valid bool
+	}{
+		{"empty", nil, true},
+		{"dag", []WorkflowStep{{Name: "a"}, {Name: "b", DependsOn: []string{"a"}}}, true},
+		{"duplicate", []WorkflowStep{{Name: "a"}, {Name: "a"}}, false},
+		{"unknown dependency", []WorkflowStep{{Name: "a", DependsOn: []string{"b"}}}, false},
+		{"self dependency", []WorkflowStep{{Name: "a", DependsOn: []string{"a"}}}, false},
+		{"cycle", []WorkflowStep{
+			{Name: "a", DependsOn: []string{"c"}},
+			{Name: "b", DependsOn: []string{"a"}},
+			{Name: "c", DependsOn: []string{"b"}}}, false},
+	}
+
+	for _, test := range tests {
+		if _, err := validateWorkflow(Workflow{Steps: test.steps}); (err == nil) != test.valid {
+			t.Errorf("%s: err = %v, want valid %v", test.name, err, test.valid)
+		}
+	}
+}